@@ -31,7 +31,6 @@ provider "azurerm" {
 }
 
 provider "sqlserver" {
-  debug = true
   host  = azurerm_sqlserver_server.sql_server.fully_qualified_domain_name
   login {
     username = azurerm_sqlserver_server.sql_server.administrator_login
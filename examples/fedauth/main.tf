@@ -32,7 +32,6 @@ provider "azurerm" {
 
 
 provider "sqlserver" {  
-  debug = true
   host  = azurerm_sqlserver_server.sql_server.fully_qualified_domain_name
   azuread_default_chain_auth {}
 }
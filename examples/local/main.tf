@@ -23,7 +23,6 @@ terraform {
 provider "docker" {}
 
 provider "sqlserver" {
-  debug = true
   host  = docker_container.mssql.network_data[0].ip_address
   login {
     username = local.local_username
@@ -0,0 +1,103 @@
+package sql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const accessTokenCommandTimeout = time.Minute
+
+// AccessToken authenticates with a database access token, either given directly
+// or produced by a helper command.
+type AccessToken struct {
+	Token   string   `json:"-"`
+	Command []string `json:"command,omitempty"`
+}
+
+// runAccessTokenCommand executes the command and parses the JSON it writes to stdout.
+func runAccessTokenCommand(ctx context.Context, command []string) (string, time.Time, error) {
+	if len(command) == 0 {
+		return "", time.Time{}, errors.New("access token command is empty")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, accessTokenCommandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// children of the command keeping its output open do not hold up the end
+	// of the context
+	cmd.WaitDelay = time.Second
+	if err := cmd.Run(); err != nil {
+		return "", time.Time{}, errors.Wrapf(err, "access token command failed: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	return parseAccessTokenOutput(stdout.Bytes())
+}
+
+// parseAccessTokenOutput reads the token and its expiry from the command output. Besides
+// access_token and expires_on, the accessToken and expiresOn fields written by
+// `az account get-access-token` are understood.
+func parseAccessTokenOutput(output []byte) (string, time.Time, error) {
+	var result struct {
+		AccessToken      string          `json:"access_token"`
+		AccessTokenCamel string          `json:"accessToken"`
+		ExpiresOn        json.RawMessage `json:"expires_on"`
+		ExpiresOnCamel   json.RawMessage `json:"expiresOn"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", time.Time{}, errors.Wrap(err, "access token command did not write valid JSON")
+	}
+
+	token := result.AccessToken
+	if token == "" {
+		token = result.AccessTokenCamel
+	}
+	if token == "" {
+		return "", time.Time{}, errors.New("access token command did not return an access_token")
+	}
+
+	expiresOn := result.ExpiresOn
+	if len(expiresOn) == 0 {
+		expiresOn = result.ExpiresOnCamel
+	}
+	expiry, err := parseTokenExpiry(expiresOn)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiry, nil
+}
+
+func parseTokenExpiry(raw json.RawMessage) (time.Time, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return time.Time{}, nil
+	}
+
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		// not a string, so it has to be a number
+		value = string(raw)
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05.999999", value, time.Local); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, errors.Errorf("unable to parse access token expiry %s", value)
+}
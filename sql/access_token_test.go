@@ -0,0 +1,148 @@
+package sql
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAccessTokenOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		output     string
+		wantToken  string
+		wantExpiry time.Time
+		wantErr    bool
+	}{
+		{
+			name:       "unix expiry",
+			output:     `{"access_token": "token", "expires_on": 1700000000}`,
+			wantToken:  "token",
+			wantExpiry: time.Unix(1700000000, 0),
+		},
+		{
+			name:       "unix expiry as string",
+			output:     `{"access_token": "token", "expires_on": "1700000000"}`,
+			wantToken:  "token",
+			wantExpiry: time.Unix(1700000000, 0),
+		},
+		{
+			name:       "RFC 3339 expiry",
+			output:     `{"access_token": "token", "expires_on": "2023-11-14T22:13:20Z"}`,
+			wantToken:  "token",
+			wantExpiry: time.Unix(1700000000, 0),
+		},
+		{
+			name:       "az cli",
+			output:     `{"accessToken": "token", "expiresOn": "2023-11-14 22:13:20.000000", "tokenType": "Bearer"}`,
+			wantToken:  "token",
+			wantExpiry: time.Date(2023, 11, 14, 22, 13, 20, 0, time.Local),
+		},
+		{
+			name:      "no expiry",
+			output:    `{"access_token": "token"}`,
+			wantToken: "token",
+		},
+		{
+			name:    "no token",
+			output:  `{"expires_on": 1700000000}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid expiry",
+			output:  `{"access_token": "token", "expires_on": "tomorrow"}`,
+			wantErr: true,
+		},
+		{
+			name:    "not JSON",
+			output:  "token",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, expiry, err := parseAccessTokenOutput([]byte(tt.output))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAccessTokenOutput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if token != tt.wantToken {
+				t.Fatalf("parseAccessTokenOutput() token = %q, want %q", token, tt.wantToken)
+			}
+			if !expiry.Equal(tt.wantExpiry) {
+				t.Fatalf("parseAccessTokenOutput() expiry = %v, want %v", expiry, tt.wantExpiry)
+			}
+		})
+	}
+}
+
+func TestRunAccessTokenCommand(t *testing.T) {
+	expiresOn := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name      string
+		command   []string
+		wantToken string
+		wantErr   string
+	}{
+		{
+			name:      "token and expiry",
+			command:   []string{"sh", "-c", `printf '{"access_token": "token", "expires_on": %s}' "$0"`, strconv.FormatInt(expiresOn.Unix(), 10)},
+			wantToken: "token",
+		},
+		{
+			name:    "failing command",
+			command: []string{"sh", "-c", "echo denied >&2; exit 1"},
+			wantErr: "denied",
+		},
+		{
+			name:    "empty command",
+			command: nil,
+			wantErr: "empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, expiry, err := runAccessTokenCommand(context.Background(), tt.command)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("runAccessTokenCommand() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("runAccessTokenCommand() error = %v", err)
+			}
+			if token != tt.wantToken || !expiry.Equal(expiresOn) {
+				t.Fatalf("runAccessTokenCommand() = %q, %v, want %q, %v", token, expiry, tt.wantToken, expiresOn)
+			}
+		})
+	}
+}
+
+func TestConnectorTokenProviderCancelled(t *testing.T) {
+	c := Connector{
+		AccessToken: &AccessToken{Command: []string{"sh", "-c", "sleep 60"}},
+		tokens:      newTokenCache(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.tokenProvider(ctx)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatalf("tokenProvider() error = nil, want the error of the cancelled command")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("tokenProvider() did not return after the context ended")
+	}
+}
@@ -0,0 +1,157 @@
+package sql
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Operation describes the resource operation a statement is executed for. It is
+// recorded in the audit log.
+type Operation struct {
+	ResourceType string
+	ResourceID   string
+	Operation    string
+}
+
+type operationKey struct{}
+
+// WithOperation returns ctx carrying the operation of the statements executed
+// with it.
+func WithOperation(ctx context.Context, operation Operation) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+func operationFrom(ctx context.Context) Operation {
+	operation, _ := ctx.Value(operationKey{}).(Operation)
+	return operation
+}
+
+// auditEntry is a line of the audit log.
+type auditEntry struct {
+	Timestamp    string   `json:"timestamp"`
+	Server       string   `json:"server"`
+	Database     string   `json:"database"`
+	Principal    string   `json:"principal"`
+	ExecuteAs    string   `json:"execute_as,omitempty"`
+	ResourceType string   `json:"resource_type,omitempty"`
+	ResourceID   string   `json:"resource_id,omitempty"`
+	Operation    string   `json:"operation,omitempty"`
+	Statement    string   `json:"statement"`
+	Arguments    []string `json:"arguments,omitempty"`
+	DurationMs   int64    `json:"duration_ms"`
+	Outcome      string   `json:"outcome"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// appendLog serializes the writes of all connectors of a provider to a file, so
+// concurrent resources do not interleave their lines.
+type appendLog struct {
+	mu sync.Mutex
+}
+
+// open opens the file for appending, creating it readable by the owner only.
+func (a *appendLog) open(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+}
+
+func (a *appendLog) write(file *os.File, content []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	_, err := file.Write(content)
+	return err
+}
+
+// appendFiles returns the append log shared by the connectors of the provider.
+// Connectors created without it serialize the writes of a single statement
+// only; the connector is not changed, as it may be shared by concurrent
+// operations.
+func (c *Connector) appendFiles() *appendLog {
+	if c.files == nil {
+		return &appendLog{}
+	}
+	return c.files
+}
+
+// openAudit opens the audit log of the connector, or returns nil if auditing is
+// disabled. Statements are only executed once the audit log could be opened.
+func (c *Connector) openAudit() (*os.File, error) {
+	if c.AuditLogPath == "" {
+		return nil, nil
+	}
+	file, err := c.appendFiles().open(c.AuditLogPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open audit log")
+	}
+	return file, nil
+}
+
+// writeAudit appends the executed statement to the audit log and closes it.
+func (c *Connector) writeAudit(ctx context.Context, file *os.File, statement string, args []interface{}, start time.Time, err error) error {
+	if file == nil {
+		return nil
+	}
+	defer file.Close()
+
+	operation := operationFrom(ctx)
+	entry := auditEntry{
+		Timestamp:    start.UTC().Format(time.RFC3339Nano),
+		Server:       c.serverName(),
+		Database:     c.Database,
+		Principal:    c.principal(),
+		ResourceType: operation.ResourceType,
+		ResourceID:   operation.ResourceID,
+		Operation:    operation.Operation,
+		Statement:    redactStatement(strings.TrimSpace(statement)),
+		Arguments:    redactArguments(args),
+		DurationMs:   time.Since(start).Milliseconds(),
+		Outcome:      "success",
+	}
+	if entry.Database == "" {
+		entry.Database = "master"
+	}
+	if c.ExecuteAs != nil {
+		entry.ExecuteAs = c.ExecuteAs.String()
+	}
+	if err != nil {
+		entry.Outcome = "error"
+		entry.Error = err.Error()
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "unable to write audit log")
+	}
+	if err := c.appendFiles().write(file, append(line, '\n')); err != nil {
+		return errors.Wrap(err, "unable to write audit log")
+	}
+	return nil
+}
+
+// principal describes the identity the connector logs in with.
+func (c *Connector) principal() string {
+	switch {
+	case c.Login != nil:
+		return c.Login.Username
+	case c.AzureLogin != nil:
+		return "azure_login:" + c.AzureLogin.ClientID
+	case c.WorkloadIdentity != nil:
+		return "workload_identity:" + c.WorkloadIdentity.ClientID
+	case c.AccessToken != nil && len(c.AccessToken.Command) > 0:
+		return "access_token_command:" + c.AccessToken.Command[0]
+	case c.AccessToken != nil:
+		return "access_token"
+	case c.FedauthMSI != nil && c.FedauthMSI.UserID != "":
+		return "managed_identity:" + c.FedauthMSI.UserID
+	case c.FedauthMSI != nil:
+		return "managed_identity"
+	default:
+		return "default_chain"
+	}
+}
@@ -0,0 +1,128 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	mssql "github.com/microsoft/go-mssqldb"
+)
+
+func readAuditLog(t *testing.T, path string) []auditEntry {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	var entries []auditEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var entry auditEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Unmarshal(%q) error = %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestConnectorAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	fake := &fakeConnector{execErrs: []error{nil, mssql.Error{Number: 15151, Message: "Cannot drop the login 'l'."}}}
+	c := &Connector{
+		Host:         "sql1",
+		Port:         "1433",
+		Login:        &LoginUser{Username: "sa", Password: "sa-password"},
+		Retry:        Retry{MaxAttempts: 1},
+		AuditLogPath: path,
+		pool:         newConnectionPool(),
+	}
+	if _, err := c.pool.get(context.Background(), c.poolKey(), func(context.Context) (*sql.DB, error) { return sql.OpenDB(fake), nil }); err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+
+	ctx := WithOperation(context.Background(), Operation{ResourceType: "sqlserver_login", ResourceID: "sqlserver://sql1:1433/login/l", Operation: "delete"})
+	if err := c.DeleteLogin(ctx, "l"); err == nil {
+		t.Fatalf("DeleteLogin() error = nil, want error")
+	}
+
+	entries := readAuditLog(t, path)
+	if len(entries) != 2 {
+		t.Fatalf("audit log has %d entries, want 2", len(entries))
+	}
+
+	kill, drop := entries[0], entries[1]
+	if _, err := time.Parse(time.RFC3339Nano, kill.Timestamp); err != nil {
+		t.Fatalf("timestamp %q error = %v", kill.Timestamp, err)
+	}
+	if !strings.Contains(kill.Statement, "KILL") || kill.Outcome != "success" {
+		t.Fatalf("first entry = %+v, want the successful KILL of the login sessions", kill)
+	}
+	want := auditEntry{
+		Timestamp:    drop.Timestamp,
+		Server:       "sql1:1433",
+		Database:     "master",
+		Principal:    "sa",
+		ResourceType: "sqlserver_login",
+		ResourceID:   "sqlserver://sql1:1433/login/l",
+		Operation:    "delete",
+		Statement:    drop.Statement,
+		Arguments:    []string{"@name = 'l'"},
+		DurationMs:   drop.DurationMs,
+		Outcome:      "error",
+		Error:        "mssql: Cannot drop the login 'l'.",
+	}
+	if !reflect.DeepEqual(drop, want) {
+		t.Fatalf("second entry = %+v, want %+v", drop, want)
+	}
+}
+
+func TestConnectorAuditLogRedactsSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	c := &Connector{Host: "sql1", Port: "1433", Database: "master", AuditLogPath: path, pool: newConnectionPool()}
+	if _, err := c.pool.get(context.Background(), c.poolKey(), func(context.Context) (*sql.DB, error) { return sql.OpenDB(&fakeConnector{}), nil }); err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+
+	if err := c.CreateLogin(context.Background(), "l", "login-password", "SQL"); err != nil {
+		t.Fatalf("CreateLogin() error = %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if strings.Contains(string(content), "login-password") {
+		t.Fatalf("audit log contains the password:\n%s", content)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("Stat() = %v, %v, want mode 0600", info.Mode(), err)
+	}
+}
+
+func TestConnectorAuditLogUnavailable(t *testing.T) {
+	fake := &fakeConnector{}
+	c := &Connector{
+		Host:         "sql1",
+		Port:         "1433",
+		AuditLogPath: filepath.Join(t.TempDir(), "missing", "audit.log"),
+		pool:         newConnectionPool(),
+	}
+	if _, err := c.pool.get(context.Background(), c.poolKey(), func(context.Context) (*sql.DB, error) { return sql.OpenDB(fake), nil }); err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+
+	if err := c.ExecContext(context.Background(), "DROP LOGIN [l]"); err == nil {
+		t.Fatalf("ExecContext() error = nil, want error")
+	}
+	if fake.execs != 0 {
+		t.Fatalf("ExecContext() executed %d times, want 0", fake.execs)
+	}
+}
@@ -0,0 +1,100 @@
+package sql
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/pkg/errors"
+)
+
+// clientCertificate loads the certificate and private key of the service principal,
+// either from ClientCertificatePath or from the inline ClientCertificate. Inline
+// certificates are PEM text or a base64 encoded PFX file.
+func (l *AzureLogin) clientCertificate() (*x509.Certificate, *rsa.PrivateKey, error) {
+	var data []byte
+	if l.ClientCertificatePath != "" {
+		var err error
+		if data, err = os.ReadFile(l.ClientCertificatePath); err != nil {
+			return nil, nil, errors.Wrap(err, "unable to read client certificate")
+		}
+	} else if strings.Contains(l.ClientCertificate, "-----BEGIN") {
+		data = []byte(l.ClientCertificate)
+	} else {
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(strings.TrimSpace(l.ClientCertificate)); err != nil {
+			return nil, nil, errors.Wrap(err, "client certificate is neither PEM nor base64 encoded PFX")
+		}
+	}
+
+	if bytes.Contains(data, []byte("-----BEGIN")) {
+		return decodePemCertificate(data)
+	}
+
+	certificate, key, err := adal.DecodePfxCertificateData(data, l.ClientCertificatePassword)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to decode PFX client certificate")
+	}
+	return certificate, key, nil
+}
+
+func (l *AzureLogin) hasClientCertificate() bool {
+	return l.ClientCertificatePath != "" || l.ClientCertificate != ""
+}
+
+func decodePemCertificate(data []byte) (*x509.Certificate, *rsa.PrivateKey, error) {
+	var (
+		certificate *x509.Certificate
+		key         *rsa.PrivateKey
+	)
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		switch block.Type {
+		case "CERTIFICATE":
+			if certificate != nil {
+				// the first certificate is the leaf, the rest is the chain
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "unable to parse client certificate")
+			}
+			certificate = cert
+		case "RSA PRIVATE KEY":
+			k, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "unable to parse client certificate key")
+			}
+			key = k
+		case "PRIVATE KEY":
+			k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "unable to parse client certificate key")
+			}
+			rsaKey, ok := k.(*rsa.PrivateKey)
+			if !ok {
+				return nil, nil, errors.New("client certificate key must be an RSA key")
+			}
+			key = rsaKey
+		case "ENCRYPTED PRIVATE KEY":
+			return nil, nil, errors.New("encrypted PEM keys are not supported, use a PFX file with a password instead")
+		}
+	}
+
+	if certificate == nil {
+		return nil, nil, errors.New("no certificate found in client certificate")
+	}
+	if key == nil {
+		return nil, nil, errors.New("no private key found in client certificate")
+	}
+	return certificate, key, nil
+}
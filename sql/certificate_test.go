@@ -0,0 +1,120 @@
+package sql
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testClientCertificate(t *testing.T) string {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "terraform-provider-sqlserver"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})) +
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer}))
+}
+
+func TestAzureLoginClientCertificate(t *testing.T) {
+	certificate := testClientCertificate(t)
+	path := filepath.Join(t.TempDir(), "client.pem")
+	if err := os.WriteFile(path, []byte(certificate), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		login   AzureLogin
+		wantErr bool
+	}{
+		{
+			name:  "inline PEM",
+			login: AzureLogin{ClientCertificate: certificate},
+		},
+		{
+			name:  "PEM file",
+			login: AzureLogin{ClientCertificatePath: path},
+		},
+		{
+			name:    "missing file",
+			login:   AzureLogin{ClientCertificatePath: filepath.Join(t.TempDir(), "missing.pfx")},
+			wantErr: true,
+		},
+		{
+			name:    "invalid inline PFX",
+			login:   AzureLogin{ClientCertificate: "bm90IGEgcGZ4"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert, key, err := tt.login.clientCertificate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("clientCertificate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (cert == nil || key == nil) {
+				t.Fatalf("clientCertificate() returned no certificate or key")
+			}
+		})
+	}
+}
+
+func TestAzureLoginServicePrincipalTokenFromCertificate(t *testing.T) {
+	const resource = "https://database.windows.net/"
+
+	server := testTokenServer(t, "certificate-token", func(form url.Values) string {
+		if form.Get("client_assertion_type") != "urn:ietf:params:oauth:client-assertion-type:jwt-bearer" || form.Get("client_assertion") == "" {
+			return "expected a client assertion"
+		}
+		if form.Get("client_secret") != "" {
+			return "unexpected client secret"
+		}
+		if form.Get("client_id") != "client" || form.Get("resource") != resource {
+			return "unexpected client or resource"
+		}
+		return ""
+	})
+
+	login := &AzureLogin{
+		TenantID:          "tenant",
+		ClientID:          "client",
+		ClientCertificate: testClientCertificate(t),
+	}
+
+	spt, err := login.servicePrincipalToken(server.URL, resource)
+	if err != nil {
+		t.Fatalf("servicePrincipalToken() error = %v", err)
+	}
+	if err = spt.EnsureFresh(); err != nil {
+		t.Fatalf("EnsureFresh() error = %v", err)
+	}
+	if token := spt.OAuthToken(); token != "certificate-token" {
+		t.Fatalf("OAuthToken() = %q, want %q", token, "certificate-token")
+	}
+}
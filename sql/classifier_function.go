@@ -40,6 +40,12 @@ func (c *Connector) GetClassifierFunction(ctx context.Context, schemaName, name
 }
 
 func (c *Connector) CreateClassifierFunction(ctx context.Context, fn *model.ClassifierFunction) error {
+	defer c.lockResourceGovernor()()
+
+	return c.createClassifierFunction(ctx, fn)
+}
+
+func (c *Connector) createClassifierFunction(ctx context.Context, fn *model.ClassifierFunction) error {
 	// The definition should be the complete CREATE FUNCTION statement body
 	// We wrap it in a proper CREATE FUNCTION statement
 	cmd := fmt.Sprintf(`CREATE FUNCTION %s.%s()
@@ -58,6 +64,8 @@ END`,
 }
 
 func (c *Connector) UpdateClassifierFunction(ctx context.Context, fn *model.ClassifierFunction) error {
+	defer c.lockResourceGovernor()()
+
 	// Drop and recreate since ALTER FUNCTION has limitations
 	cmd := fmt.Sprintf(`
 		-- First check if this function is the classifier and temporarily remove it
@@ -83,10 +91,12 @@ func (c *Connector) UpdateClassifierFunction(ctx context.Context, fn *model.Clas
 	}
 
 	// Create the new function
-	return c.CreateClassifierFunction(ctx, fn)
+	return c.createClassifierFunction(ctx, fn)
 }
 
 func (c *Connector) DeleteClassifierFunction(ctx context.Context, schemaName, name string) error {
+	defer c.lockResourceGovernor()()
+
 	// First remove from resource governor if it's the classifier
 	cmd := fmt.Sprintf(`
 		IF EXISTS (SELECT 1 FROM sys.resource_governor_configuration WHERE classifier_function_id = OBJECT_ID('%s.%s'))
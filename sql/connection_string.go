@@ -0,0 +1,139 @@
+package sql
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/microsoft/go-mssqldb/msdsn"
+	"github.com/pkg/errors"
+)
+
+// driverParameters are the parameters of go-mssqldb that are passed through to
+// the driver. The TLS parameters are overridden by the tls block of the provider.
+var driverParameters = map[string]bool{
+	msdsn.PacketSize:             true,
+	msdsn.LogParam:               true,
+	msdsn.ConnectionTimeout:      true,
+	msdsn.DialTimeout:            true,
+	msdsn.KeepAlive:              true,
+	msdsn.ServerSpn:              true,
+	msdsn.ApplicationIntent:      true,
+	msdsn.FailoverPartner:        true,
+	msdsn.FailOverPort:           true,
+	msdsn.DisableRetry:           true,
+	msdsn.Protocol:               true,
+	msdsn.Encrypt:                true,
+	msdsn.TrustServerCertificate: true,
+	msdsn.Certificate:            true,
+	msdsn.TLSMin:                 true,
+	msdsn.HostNameInCertificate:  true,
+	"columnencryption":           true,
+}
+
+// providerParameters are the parameters of go-mssqldb set by the arguments of
+// the provider, which cannot be passed through.
+var providerParameters = map[string]string{
+	msdsn.Server:        "host",
+	msdsn.Port:          "port",
+	msdsn.UserID:        "login",
+	msdsn.Password:      "login",
+	msdsn.Database:      "the resources",
+	msdsn.AppName:       "application_name",
+	msdsn.WorkstationID: "workstation_id",
+	"fedauth":           "the login blocks",
+}
+
+// ConnectionString is a connection string split into the server, the login and
+// the parameters passed through to the driver.
+type ConnectionString struct {
+	Host       string
+	Port       string
+	Instance   string
+	Username   string
+	Password   string
+	Parameters map[string]string
+}
+
+// ParseConnectionString parses a connection string in the URL, ADO or ODBC
+// format of go-mssqldb.
+func ParseConnectionString(connectionString string) (ConnectionString, error) {
+	config, err := msdsn.Parse(connectionString)
+	if err != nil {
+		return ConnectionString{}, errors.Wrap(err, "invalid connection string")
+	}
+
+	parameters := map[string]string{}
+	for key, value := range config.Parameters {
+		parameters[key] = value
+	}
+
+	var result ConnectionString
+	result.Host = parameters[msdsn.Server]
+	result.Port = parameters[msdsn.Port]
+	// ODBC connection strings are not split into the server and port by the driver
+	if i := strings.LastIndexByte(result.Host, ','); i >= 0 && result.Port == "" {
+		result.Host, result.Port = result.Host[:i], result.Host[i+1:]
+	}
+	if i := strings.IndexByte(result.Host, '\\'); i >= 0 {
+		result.Host, result.Instance = result.Host[:i], result.Host[i+1:]
+	}
+	result.Username = parameters[msdsn.UserID]
+	result.Password = parameters[msdsn.Password]
+	for _, key := range []string{msdsn.Server, msdsn.Port, msdsn.UserID, msdsn.Password} {
+		delete(parameters, key)
+	}
+	if result.Host == "" {
+		return ConnectionString{}, errors.New("invalid connection string: no server")
+	}
+
+	if result.Parameters, err = ValidateConnectionParameters(parameters); err != nil {
+		return ConnectionString{}, errors.Wrap(err, "invalid connection string")
+	}
+	return result, nil
+}
+
+// ValidateConnectionParameters returns the parameters with lower case keys, as
+// used by the driver, if the driver supports them and accepts their values.
+func ValidateConnectionParameters(parameters map[string]string) (map[string]string, error) {
+	query := url.Values{}
+	for key, value := range parameters {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if argument, ok := providerParameters[key]; ok {
+			return nil, errors.Errorf("parameter %q is set by %s", key, argument)
+		}
+		if !driverParameters[key] {
+			return nil, errors.Errorf("unsupported parameter %q, supported are %s", key, strings.Join(supportedParameters(), ", "))
+		}
+		query.Set(key, value)
+	}
+
+	normalized := make(map[string]string, len(query))
+	for key := range query {
+		normalized[key] = query.Get(key)
+	}
+
+	// the database is set by the resources, it is required with a read-only intent
+	query.Set(msdsn.Database, "master")
+	if _, err := msdsn.Parse((&url.URL{Scheme: "sqlserver", Host: "localhost", RawQuery: query.Encode()}).String()); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+func supportedParameters() []string {
+	keys := make([]string, 0, len(driverParameters))
+	for key := range driverParameters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// setParameters adds the parameters to the query, before the parameters set by
+// the connector.
+func (c *Connector) setParameters(query url.Values) {
+	for key, value := range c.Parameters {
+		query.Set(key, value)
+	}
+}
@@ -0,0 +1,206 @@
+package sql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/pkg/errors"
+)
+
+const credentialHelperTimeout = time.Minute
+
+// credentialRequest is written to the stdin of the credential helper. Credential
+// is the secret asked for, password for a SQL login or client_secret for an
+// Azure AD login.
+type credentialRequest struct {
+	Protocol   string `json:"protocol"`
+	Host       string `json:"host"`
+	Port       string `json:"port,omitempty"`
+	Instance   string `json:"instance,omitempty"`
+	Credential string `json:"credential"`
+	Username   string `json:"username,omitempty"`
+	TenantID   string `json:"tenant_id,omitempty"`
+	ClientID   string `json:"client_id,omitempty"`
+}
+
+// credential is the answer of the credential helper. The username is only used
+// if the login has none.
+type credential struct {
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	ClientSecret string `json:"client_secret"`
+}
+
+// secret returns the secret of the request.
+func (c credential) secret(request credentialRequest) string {
+	if request.Credential == "client_secret" {
+		return c.ClientSecret
+	}
+	return c.Password
+}
+
+// credentialCache keeps the credentials of the helper for the lifetime of the
+// provider, so the helper runs once per login. Failures are not cached.
+type credentialCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedCredential
+	// fetched are the secrets returned by the helper, masked in the logs.
+	fetched []string
+}
+
+// cachedCredential is the credential of a login. Its mutex is held while the
+// helper runs, so concurrent connects of the login run the helper once, while
+// the connects of other logins do not wait for it.
+type cachedCredential struct {
+	mu         sync.Mutex
+	credential credential
+	ok         bool
+}
+
+func newCredentialCache() *credentialCache {
+	return &credentialCache{entries: map[string]*cachedCredential{}}
+}
+
+// get returns the cached credential of the request, running the helper if there
+// is none.
+func (c *credentialCache) get(ctx context.Context, command []string, request credentialRequest) (credential, error) {
+	if c == nil {
+		return runCredentialHelper(ctx, command, request)
+	}
+
+	key := tokenKey(append([]string{request.Protocol, request.Host, request.Port, request.Instance,
+		request.Credential, request.Username, request.TenantID, request.ClientID}, command...)...)
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &cachedCredential{}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.ok {
+		return entry.credential, nil
+	}
+	cred, err := runCredentialHelper(ctx, command, request)
+	if err != nil {
+		return credential{}, err
+	}
+	entry.credential, entry.ok = cred, true
+
+	c.mu.Lock()
+	c.fetched = append(c.fetched, cred.secret(request))
+	c.mu.Unlock()
+	return cred, nil
+}
+
+// secrets returns the secrets the helper returned so far.
+func (c *credentialCache) secrets() []string {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.fetched...)
+}
+
+// runCredentialHelper executes the helper with the argument get, like the
+// credential helpers of git, writes the request as JSON to its stdin and reads
+// the credential from the JSON it writes to stdout.
+func runCredentialHelper(ctx context.Context, command []string, request credentialRequest) (credential, error) {
+	if len(command) == 0 {
+		return credential{}, errors.New("credential helper is empty")
+	}
+
+	input, err := json.Marshal(request)
+	if err != nil {
+		return credential{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, credentialHelperTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	args := append(append([]string{}, command[1:]...), "get")
+	cmd := exec.CommandContext(ctx, command[0], args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return credential{}, errors.Wrapf(err, "credential helper failed: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	var cred credential
+	if err := json.Unmarshal(stdout.Bytes(), &cred); err != nil {
+		return credential{}, errors.Wrap(err, "credential helper did not write valid JSON")
+	}
+	if cred.secret(request) == "" {
+		return credential{}, errors.Errorf("credential helper did not return a %s for %s", request.Credential, request.Host)
+	}
+	return cred, nil
+}
+
+// withCredentials returns a copy of the connector with the password of the SQL
+// login or the client secret of the Azure AD login fetched from the
+// CredentialHelper, if the login has none. Other connectors are returned as is.
+func (c *Connector) withCredentials(ctx context.Context) (*Connector, error) {
+	if len(c.CredentialHelper) == 0 {
+		return c, nil
+	}
+
+	request := credentialRequest{
+		Protocol: "sqlserver",
+		Host:     c.Host,
+		Port:     c.Port,
+		Instance: c.Instance,
+	}
+	connector := *c
+	switch {
+	case c.Login != nil && c.Login.Password == "":
+		request.Credential = "password"
+		request.Username = c.Login.Username
+		cred, err := c.credentials.get(ctx, c.CredentialHelper, request)
+		if err != nil {
+			return nil, err
+		}
+		login := *c.Login
+		if login.Username == "" {
+			login.Username = cred.Username
+		}
+		login.Password = cred.Password
+		connector.Login = &login
+	case c.AzureLogin != nil && c.AzureLogin.ClientSecret == "" && !c.AzureLogin.hasClientCertificate():
+		request.Credential = "client_secret"
+		request.TenantID = c.AzureLogin.TenantID
+		request.ClientID = c.AzureLogin.ClientID
+		cred, err := c.credentials.get(ctx, c.CredentialHelper, request)
+		if err != nil {
+			return nil, err
+		}
+		azureLogin := *c.AzureLogin
+		azureLogin.ClientSecret = cred.ClientSecret
+		connector.AzureLogin = &azureLogin
+	default:
+		return c, nil
+	}
+	return &connector, nil
+}
+
+// maskCredentials masks the secrets returned by the credential helper in the
+// logs written with the returned context.
+func (c *Connector) maskCredentials(ctx context.Context) context.Context {
+	secrets := c.credentials.secrets()
+	if len(secrets) == 0 {
+		return ctx
+	}
+	ctx = tflog.SubsystemMaskLogStrings(ctx, LogSubsystem, secrets...)
+	return tflog.MaskLogStrings(ctx, secrets...)
+}
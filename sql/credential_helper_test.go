@@ -0,0 +1,231 @@
+package sql
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+func TestRunCredentialHelper(t *testing.T) {
+	request := credentialRequest{Protocol: "sqlserver", Host: "sql1.example.com", Port: "1433", Credential: "password", Username: "terraform"}
+
+	tests := []struct {
+		name    string
+		command []string
+		want    credential
+		wantErr string
+	}{
+		{
+			name: "password",
+			// echoes the action and the username of the request as the password
+			command: []string{"sh", "-c", `sed -e 's/.*"username":"\([^"]*\)".*/{"password": "'"$0"'-\1"}/'`},
+			want:    credential{Password: "get-terraform"},
+		},
+		{
+			name:    "username and password",
+			command: []string{"sh", "-c", `echo '{"username": "deployer", "password": "secret"}'`},
+			want:    credential{Username: "deployer", Password: "secret"},
+		},
+		{
+			name:    "no password",
+			command: []string{"sh", "-c", `echo '{"client_secret": "secret"}'`},
+			wantErr: "did not return a password for sql1.example.com",
+		},
+		{
+			name:    "not JSON",
+			command: []string{"sh", "-c", "echo secret"},
+			wantErr: "valid JSON",
+		},
+		{
+			name:    "failing command",
+			command: []string{"sh", "-c", "echo denied >&2; exit 1"},
+			wantErr: "denied",
+		},
+		{
+			name:    "empty command",
+			command: nil,
+			wantErr: "empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runCredentialHelper(context.Background(), tt.command, request)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("runCredentialHelper() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("runCredentialHelper() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("runCredentialHelper() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnectorWithCredentials(t *testing.T) {
+	calls := filepath.Join(t.TempDir(), "calls")
+	// records every call and answers with the credential asked for
+	helper := []string{"sh", "-c", `echo >> "$1"; case $(cat) in *client_secret*) echo '{"client_secret": "helper-secret"}';; *) echo '{"password": "helper-password"}';; esac`, "helper", calls}
+
+	tests := []struct {
+		name      string
+		connector Connector
+		wantLogin *LoginUser
+		wantAzure *AzureLogin
+		wantCalls int
+		connects  int
+	}{
+		{
+			name:      "password",
+			connector: Connector{Login: &LoginUser{Username: "terraform"}},
+			wantLogin: &LoginUser{Username: "terraform", Password: "helper-password"},
+			wantCalls: 1,
+			connects:  3,
+		},
+		{
+			name:      "configured password",
+			connector: Connector{Login: &LoginUser{Username: "terraform", Password: "password"}},
+			wantLogin: &LoginUser{Username: "terraform", Password: "password"},
+			connects:  1,
+		},
+		{
+			name:      "client secret",
+			connector: Connector{AzureLogin: &AzureLogin{TenantID: "tenant", ClientID: "client"}},
+			wantAzure: &AzureLogin{TenantID: "tenant", ClientID: "client", ClientSecret: "helper-secret"},
+			wantCalls: 1,
+			connects:  2,
+		},
+		{
+			name:      "client certificate",
+			connector: Connector{AzureLogin: &AzureLogin{TenantID: "tenant", ClientID: "client", ClientCertificatePath: "client.pem"}},
+			wantAzure: &AzureLogin{TenantID: "tenant", ClientID: "client", ClientCertificatePath: "client.pem"},
+			connects:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(calls)
+			c := tt.connector
+			c.Host = "sql1.example.com"
+			c.Port = "1433"
+			c.CredentialHelper = helper
+			c.credentials = newCredentialCache()
+
+			for i := 0; i < tt.connects; i++ {
+				got, err := c.withCredentials(context.Background())
+				if err != nil {
+					t.Fatalf("withCredentials() error = %v", err)
+				}
+				if tt.wantLogin != nil && *got.Login != *tt.wantLogin {
+					t.Fatalf("withCredentials() login = %+v, want %+v", got.Login, tt.wantLogin)
+				}
+				if tt.wantAzure != nil && *got.AzureLogin != *tt.wantAzure {
+					t.Fatalf("withCredentials() Azure login = %+v, want %+v", got.AzureLogin, tt.wantAzure)
+				}
+			}
+
+			output, _ := os.ReadFile(calls)
+			if got := strings.Count(string(output), "\n"); got != tt.wantCalls {
+				t.Fatalf("credential helper ran %d times, want %d", got, tt.wantCalls)
+			}
+			if c.Login != nil && c.Login.Password != tt.connector.Login.Password {
+				t.Fatalf("withCredentials() changed the connector password to %q", c.Login.Password)
+			}
+		})
+	}
+}
+
+func TestCredentialCacheConcurrentLogins(t *testing.T) {
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	release := filepath.Join(dir, "release")
+	// the helper of the login blocked waits until release exists
+	helper := []string{"sh", "-c", `echo >> "$1"; case $(cat) in *'"username":"blocked"'*) while [ ! -e "$2" ]; do sleep 0.01; done;; esac; echo '{"password": "helper-password"}'`, "helper", calls, release}
+	cache := newCredentialCache()
+	request := func(username string) credentialRequest {
+		return credentialRequest{Protocol: "sqlserver", Host: "sql1.example.com", Credential: "password", Username: username}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache.get(context.Background(), helper, request("blocked"))
+			errs <- err
+		}()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := cache.get(context.Background(), helper, request("terraform"))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("get() error = %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("get() of another login waited for the blocked helper")
+	}
+
+	if err := os.WriteFile(release, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("get() error = %v", err)
+		}
+	}
+
+	output, _ := os.ReadFile(calls)
+	if got := strings.Count(string(output), "\n"); got != 2 {
+		t.Fatalf("credential helper ran %d times, want 2", got)
+	}
+}
+
+func TestConnectorMaskCredentials(t *testing.T) {
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+	ctx = tflog.NewSubsystem(ctx, LogSubsystem)
+
+	c := Connector{
+		Host:             "sql1.example.com",
+		Login:            &LoginUser{Username: "terraform"},
+		CredentialHelper: []string{"sh", "-c", `echo '{"password": "helper-password"}'`},
+		credentials:      newCredentialCache(),
+	}
+	if _, err := c.withCredentials(ctx); err != nil {
+		t.Fatalf("withCredentials() error = %v", err)
+	}
+
+	ctx = c.maskCredentials(ctx)
+	tflog.Warn(ctx, "login failed for helper-password")
+	tflog.SubsystemDebug(ctx, LogSubsystem, "executed statement", map[string]interface{}{"error": "login failed for helper-password"})
+
+	logs := output.String()
+	if strings.Count(logs, "login failed") != 2 {
+		t.Fatalf("logs do not contain the messages:\n%s", logs)
+	}
+	if strings.Contains(logs, "helper-password") {
+		t.Fatalf("logs contain the fetched password:\n%s", logs)
+	}
+}
@@ -0,0 +1,149 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DryRun renders the statements of ExecContext as a T-SQL script instead of
+// executing them. Queries still run, so write operations can read the current
+// state they depend on.
+type DryRun struct {
+	Enabled bool
+	// ScriptPath is the file the rendered statements are appended to.
+	ScriptPath string
+}
+
+// Script collects the statements rendered during a dry run.
+type Script struct {
+	mu         sync.Mutex
+	statements []string
+}
+
+type scriptKey struct{}
+
+// WithScript returns ctx collecting the statements rendered with it in the
+// returned Script.
+func WithScript(ctx context.Context) (context.Context, *Script) {
+	script := &Script{}
+	return context.WithValue(ctx, scriptKey{}, script), script
+}
+
+func (s *Script) add(statement string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.statements = append(s.statements, statement)
+}
+
+// Empty reports whether no statements were rendered.
+func (s *Script) Empty() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.statements) == 0
+}
+
+func (s *Script) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return strings.Join(s.statements, "\n")
+}
+
+// renderScript renders the statement, adds it to the script of ctx and appends
+// it to the script file of the dry run.
+func (c *Connector) renderScript(ctx context.Context, statement string, args []interface{}) error {
+	rendered := c.render(ctx, statement, args)
+
+	if script, ok := ctx.Value(scriptKey{}).(*Script); ok {
+		script.add(rendered)
+	}
+
+	if c.DryRun.ScriptPath == "" {
+		return nil
+	}
+	files := c.appendFiles()
+	file, err := files.open(c.DryRun.ScriptPath)
+	if err != nil {
+		return errors.Wrap(err, "unable to open dry run script")
+	}
+	defer file.Close()
+
+	if err := files.write(file, []byte(rendered+"\n")); err != nil {
+		return errors.Wrap(err, "unable to write dry run script")
+	}
+	return nil
+}
+
+// render returns a batch that can be run with sqlcmd: a header describing the
+// operation, the database, the arguments declared as variables and the
+// statement. Secrets are redacted.
+func (c *Connector) render(ctx context.Context, statement string, args []interface{}) string {
+	var b strings.Builder
+
+	operation := operationFrom(ctx)
+	fmt.Fprintf(&b, "-- %s", time.Now().UTC().Format(time.RFC3339))
+	if operation.ResourceType != "" {
+		fmt.Fprintf(&b, " %s %s", operation.Operation, operation.ResourceType)
+	}
+	if operation.ResourceID != "" {
+		fmt.Fprintf(&b, " %s", operation.ResourceID)
+	}
+	fmt.Fprintf(&b, " on %s\n", c.serverName())
+
+	database := c.Database
+	if database == "" {
+		database = "master"
+	}
+	fmt.Fprintf(&b, "USE %s\n", quoteName(database))
+
+	for i, arg := range args {
+		name, value := fmt.Sprintf("p%d", i+1), arg
+		if named, ok := arg.(sql.NamedArg); ok {
+			name, value = named.Name, named.Value
+		}
+		sqlType, literal := sqlLiteral(value)
+		if isSecret(name) {
+			literal = "N'" + redacted + "'"
+		}
+		fmt.Fprintf(&b, "DECLARE @%s %s = %s\n", name, sqlType, literal)
+	}
+
+	if c.ExecuteAs != nil {
+		fmt.Fprintf(&b, "%s\n", c.ExecuteAs.statement())
+	}
+	fmt.Fprintf(&b, "%s\n", redactStatement(strings.TrimSpace(statement)))
+	if c.ExecuteAs != nil {
+		fmt.Fprintf(&b, "REVERT\n")
+	}
+	fmt.Fprintf(&b, "GO\n")
+	return b.String()
+}
+
+// sqlLiteral returns the type and literal of an argument.
+func sqlLiteral(value interface{}) (string, string) {
+	switch v := value.(type) {
+	case nil:
+		return "sql_variant", "NULL"
+	case string:
+		return "nvarchar(max)", "N'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case bool:
+		if v {
+			return "bit", "1"
+		}
+		return "bit", "0"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return "bigint", fmt.Sprintf("%d", v)
+	case float32, float64:
+		return "float", fmt.Sprintf("%v", v)
+	default:
+		return "nvarchar(max)", "N'" + strings.ReplaceAll(fmt.Sprintf("%v", v), "'", "''") + "'"
+	}
+}
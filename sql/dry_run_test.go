@@ -0,0 +1,105 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConnectorRender(t *testing.T) {
+	tests := []struct {
+		name      string
+		database  string
+		executeAs *ExecuteAs
+		operation Operation
+		statement string
+		args      []interface{}
+		want      []string
+	}{
+		{
+			name:      "named arguments",
+			operation: Operation{ResourceType: "sqlserver_login", Operation: "create"},
+			statement: "\n  EXEC sp_addlogin @name, @password\n",
+			args:      []interface{}{sql.Named("name", "o'brien"), sql.Named("password", "login-password")},
+			want: []string{
+				" create sqlserver_login on sql1:1433\n",
+				"USE [master]\n",
+				"DECLARE @name nvarchar(max) = N'o''brien'\n",
+				"DECLARE @password nvarchar(max) = N'<redacted>'\n",
+				"EXEC sp_addlogin @name, @password\nGO\n",
+			},
+		},
+		{
+			name:      "positional arguments",
+			database:  "app",
+			operation: Operation{ResourceType: "sqlserver_user", ResourceID: "sqlserver://sql1:1433/app/u", Operation: "update"},
+			statement: "ALTER ROLE [db_owner] ADD MEMBER [u]",
+			args:      []interface{}{int64(42), true, nil},
+			want: []string{
+				" update sqlserver_user sqlserver://sql1:1433/app/u on sql1:1433\n",
+				"USE [app]\n",
+				"DECLARE @p1 bigint = 42\n",
+				"DECLARE @p2 bit = 1\n",
+				"DECLARE @p3 sql_variant = NULL\n",
+			},
+		},
+		{
+			name:      "execute as",
+			executeAs: &ExecuteAs{Type: "USER", Name: "deployer"},
+			statement: "DROP USER [u]",
+			want:      []string{"EXECUTE AS USER = N'deployer'\nDROP USER [u]\nREVERT\nGO\n"},
+		},
+		{
+			name:      "secret literal",
+			statement: "ALTER LOGIN [l] WITH PASSWORD = 'login-password'",
+			want:      []string{"ALTER LOGIN [l] WITH PASSWORD = '<redacted>'\nGO\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Connector{Host: "sql1", Port: "1433", Database: tt.database, ExecuteAs: tt.executeAs}
+			got := c.render(WithOperation(context.Background(), tt.operation), tt.statement, tt.args)
+
+			if strings.Contains(got, "login-password") {
+				t.Fatalf("render() = %q, contains the password", got)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Fatalf("render() = %q, want %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConnectorExecContextDryRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "change.sql")
+	// the host does not resolve, so executing the statements would fail
+	c := &Connector{Host: "sql1.invalid", Port: "1433", DryRun: DryRun{Enabled: true, ScriptPath: path}}
+
+	ctx, script := WithScript(context.Background())
+	if err := c.CreateLogin(ctx, "l", "login-password", "SQL"); err != nil {
+		t.Fatalf("CreateLogin() error = %v", err)
+	}
+	if err := c.DeleteLogin(ctx, "old"); err != nil {
+		t.Fatalf("DeleteLogin() error = %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if got := strings.TrimSpace(string(content)); got != strings.TrimSpace(script.String()) {
+		t.Fatalf("script file = %q, want %q", got, script.String())
+	}
+	if !strings.Contains(script.String(), "'WITH PASSWORD = ' + QuoteName(@password, '''')") {
+		t.Fatalf("script = %q, want the dynamic SQL unchanged", script)
+	}
+	if got := strings.Count(script.String(), "\nGO\n"); got != 3 {
+		t.Fatalf("script has %d batches, want 3:\n%s", got, script)
+	}
+}
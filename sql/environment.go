@@ -0,0 +1,122 @@
+package sql
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+)
+
+// Environment selects the Azure cloud used by the Azure AD login methods.
+// AuthorityHost and TokenAudience override the values of the cloud and are
+// required for the custom environment.
+type Environment struct {
+	Name          string `json:"name,omitempty"`
+	AuthorityHost string `json:"authority_host,omitempty"`
+	TokenAudience string `json:"token_audience,omitempty"`
+}
+
+var azureEnvironments = map[string]azure.Environment{
+	"":             azure.PublicCloud,
+	"public":       azure.PublicCloud,
+	"usgovernment": azure.USGovernmentCloud,
+	"china":        azure.ChinaCloud,
+}
+
+func (e Environment) cloud() (*azure.Environment, error) {
+	if e.Name == "custom" {
+		return nil, nil
+	}
+	env, ok := azureEnvironments[e.Name]
+	if !ok {
+		return nil, errors.Errorf("unknown environment %q", e.Name)
+	}
+	return &env, nil
+}
+
+// authorityHost returns the Azure AD endpoint that issues the tokens.
+func (e Environment) authorityHost() (string, error) {
+	if e.AuthorityHost != "" {
+		return e.AuthorityHost, nil
+	}
+	env, err := e.cloud()
+	if err != nil {
+		return "", err
+	}
+	if env == nil {
+		return "", errors.New("authority host is required for the custom environment")
+	}
+	return env.ActiveDirectoryEndpoint, nil
+}
+
+// tokenAudience returns the resource the tokens are issued for, the SQL
+// Database endpoint of the cloud by default.
+func (e Environment) tokenAudience() (string, error) {
+	if e.TokenAudience != "" {
+		return e.TokenAudience, nil
+	}
+	env, err := e.cloud()
+	if err != nil {
+		return "", err
+	}
+	if env == nil {
+		return "", errors.New("token audience is required for the custom environment")
+	}
+	return "https://" + env.SQLDatabaseDNSSuffix + "/", nil
+}
+
+// audienceScope returns the OAuth 2.0 scope of the token audience.
+func audienceScope(audience string) string {
+	if strings.HasSuffix(audience, "/.default") {
+		return audience
+	}
+	return strings.TrimRight(audience, "/") + "/.default"
+}
+
+func (e Environment) clientOptions() (azcore.ClientOptions, error) {
+	authorityHost, err := e.authorityHost()
+	if err != nil {
+		return azcore.ClientOptions{}, err
+	}
+	return azcore.ClientOptions{
+		Cloud: cloud.Configuration{
+			ActiveDirectoryAuthorityHost: authorityHost,
+			Services:                     map[cloud.ServiceName]cloud.ServiceConfiguration{},
+		},
+	}, nil
+}
+
+// credential returns the managed identity credential if the connector uses
+// managed identity and the default credential chain otherwise.
+func (c *Connector) credential() (azcore.TokenCredential, error) {
+	options, err := c.Environment.clientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.FedauthMSI != nil {
+		msiOptions := &azidentity.ManagedIdentityCredentialOptions{ClientOptions: options}
+		if c.FedauthMSI.UserID != "" {
+			msiOptions.ID = azidentity.ClientID(c.FedauthMSI.UserID)
+		}
+		return azidentity.NewManagedIdentityCredential(msiOptions)
+	}
+
+	return azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{ClientOptions: options})
+}
+
+func credentialTokenFetcher(credential azcore.TokenCredential, scope string) tokenFetcher {
+	return func(ctx context.Context) (string, time.Time, error) {
+		token, err := credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{scope}})
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		return token.Token, token.ExpiresOn, nil
+	}
+}
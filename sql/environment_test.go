@@ -0,0 +1,101 @@
+package sql
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+func TestEnvironment(t *testing.T) {
+	tests := []struct {
+		name          string
+		environment   Environment
+		wantAuthority string
+		wantAudience  string
+		wantErr       bool
+	}{
+		{
+			name:          "default",
+			environment:   Environment{},
+			wantAuthority: "https://login.microsoftonline.com/",
+			wantAudience:  "https://database.windows.net/",
+		},
+		{
+			name:          "us government",
+			environment:   Environment{Name: "usgovernment"},
+			wantAuthority: "https://login.microsoftonline.us/",
+			wantAudience:  "https://database.usgovcloudapi.net/",
+		},
+		{
+			name:          "china",
+			environment:   Environment{Name: "china"},
+			wantAuthority: "https://login.chinacloudapi.cn/",
+			wantAudience:  "https://database.chinacloudapi.cn/",
+		},
+		{
+			name:          "public with overridden audience",
+			environment:   Environment{Name: "public", TokenAudience: "https://sql.azuresynapse.net/"},
+			wantAuthority: "https://login.microsoftonline.com/",
+			wantAudience:  "https://sql.azuresynapse.net/",
+		},
+		{
+			name:          "custom",
+			environment:   Environment{Name: "custom", AuthorityHost: "https://login.example.com/", TokenAudience: "https://database.example.com/"},
+			wantAuthority: "https://login.example.com/",
+			wantAudience:  "https://database.example.com/",
+		},
+		{
+			name:        "custom without overrides",
+			environment: Environment{Name: "custom"},
+			wantErr:     true,
+		},
+		{
+			name:        "unknown",
+			environment: Environment{Name: "germany"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authority, err := tt.environment.authorityHost()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("authorityHost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			audience, err := tt.environment.tokenAudience()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("tokenAudience() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if authority != tt.wantAuthority {
+				t.Fatalf("authorityHost() = %s, want %s", authority, tt.wantAuthority)
+			}
+			if audience != tt.wantAudience {
+				t.Fatalf("tokenAudience() = %s, want %s", audience, tt.wantAudience)
+			}
+		})
+	}
+}
+
+func TestConnectorTokenProviderEnvironment(t *testing.T) {
+	const audience = "https://database.example.com/"
+
+	server := testTokenServer(t, "custom-token", func(form url.Values) string {
+		if form.Get("resource") != audience {
+			return "unexpected resource " + form.Get("resource")
+		}
+		return ""
+	})
+
+	connector := &Connector{
+		AzureLogin:  &AzureLogin{TenantID: "tenant", ClientID: "client", ClientSecret: "secret"},
+		Environment: Environment{Name: "custom", AuthorityHost: server.URL, TokenAudience: audience},
+	}
+
+	token, err := connector.tokenProvider(context.Background())
+	if err != nil {
+		t.Fatalf("tokenProvider() error = %v", err)
+	}
+	if token != "custom-token" {
+		t.Fatalf("tokenProvider() = %q, want %q", token, "custom-token")
+	}
+}
@@ -0,0 +1,118 @@
+package sql
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/pkg/errors"
+)
+
+const redacted = "<redacted>"
+
+// secretKeyword matches the text preceding the string literal of a secret.
+var secretKeyword = regexp.MustCompile(`(?i)\b(PASSWORD|SECRET)\s*=\s*N?$`)
+
+// StatementError is returned when SQL Server rejects a statement. It keeps the
+// statement and its arguments with secrets redacted, so they can be shown to
+// the user.
+type StatementError struct {
+	Statement string
+	Arguments []string
+	Err       error
+}
+
+func (e *StatementError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StatementError) Unwrap() error {
+	return e.Err
+}
+
+// statementError wraps errors returned by the server in a StatementError, other
+// errors are returned unchanged.
+func statementError(err error, statement string, args []interface{}) error {
+	var sqlErr mssql.Error
+	if err == nil || !errors.As(err, &sqlErr) {
+		return err
+	}
+
+	return &StatementError{
+		Statement: redactStatement(statement),
+		Arguments: redactArguments(args),
+		Err:       err,
+	}
+}
+
+// redactStatement replaces the string literals following PASSWORD = and
+// SECRET = by <redacted>. Keywords inside literals, like the dynamic SQL built
+// by the connector, are left alone as their values are passed as arguments.
+func redactStatement(statement string) string {
+	redactedStatement := make([]byte, 0, len(statement))
+	for i := 0; i < len(statement); {
+		if statement[i] != '\'' {
+			redactedStatement = append(redactedStatement, statement[i])
+			i++
+			continue
+		}
+
+		end := i + 1
+		for end < len(statement) {
+			if statement[end] == '\'' {
+				if end+1 < len(statement) && statement[end+1] == '\'' {
+					end += 2
+					continue
+				}
+				end++
+				break
+			}
+			end++
+		}
+
+		if secretKeyword.Match(redactedStatement) {
+			redactedStatement = bytes.TrimRight(redactedStatement, "Nn")
+			redactedStatement = append(redactedStatement, "'"+redacted+"'"...)
+		} else {
+			redactedStatement = append(redactedStatement, statement[i:end]...)
+		}
+		i = end
+	}
+	return string(redactedStatement)
+}
+
+// redactArguments formats the arguments as `@name = value`, hiding the values
+// of arguments whose name refers to a secret.
+func redactArguments(args []interface{}) []string {
+	arguments := make([]string, 0, len(args))
+	for i, arg := range args {
+		name := fmt.Sprintf("p%d", i+1)
+		value := arg
+		if named, ok := arg.(sql.NamedArg); ok {
+			name, value = named.Name, named.Value
+		}
+
+		formatted := fmt.Sprintf("%v", value)
+		if s, ok := value.(string); ok {
+			formatted = "'" + strings.ReplaceAll(s, "'", "''") + "'"
+		}
+		if isSecret(name) {
+			formatted = redacted
+		}
+		arguments = append(arguments, fmt.Sprintf("@%s = %s", name, formatted))
+	}
+	return arguments
+}
+
+func isSecret(name string) bool {
+	name = strings.ToLower(name)
+	for _, secret := range []string{"password", "secret", "token"} {
+		if strings.Contains(name, secret) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package sql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/pkg/errors"
+)
+
+func TestStatementError(t *testing.T) {
+	serverErr := mssql.Error{Number: 15025, Message: "The server principal 'l' already exists."}
+
+	tests := []struct {
+		name          string
+		err           error
+		statement     string
+		args          []interface{}
+		wantStatement string
+		wantArguments []string
+	}{
+		{
+			name:          "named arguments",
+			err:           serverErr,
+			statement:     "EXEC (@sql)",
+			args:          []interface{}{sql.Named("name", "l"), sql.Named("password", "s3cr'et"), sql.Named("sourceType", "SQL")},
+			wantStatement: "EXEC (@sql)",
+			wantArguments: []string{"@name = 'l'", "@password = <redacted>", "@sourceType = 'SQL'"},
+		},
+		{
+			name:          "positional arguments",
+			err:           errors.Wrap(serverErr, "in exec"),
+			statement:     "KILL @p1",
+			args:          []interface{}{52},
+			wantStatement: "KILL @p1",
+			wantArguments: []string{"@p1 = 52"},
+		},
+		{
+			name:          "secret literals",
+			err:           serverErr,
+			statement:     "CREATE LOGIN [l] WITH PASSWORD = N'it''s secret', CHECK_POLICY = OFF",
+			wantStatement: "CREATE LOGIN [l] WITH PASSWORD = '<redacted>', CHECK_POLICY = OFF",
+			wantArguments: []string{},
+		},
+		{
+			name:          "dynamic sql",
+			err:           serverErr,
+			statement:     "SET @sql = 'ALTER LOGIN ' + QuoteName(@name) + ' WITH PASSWORD = ' + QuoteName(@password, '''')",
+			args:          []interface{}{sql.Named("name", "l"), sql.Named("password", "secret")},
+			wantStatement: "SET @sql = 'ALTER LOGIN ' + QuoteName(@name) + ' WITH PASSWORD = ' + QuoteName(@password, '''')",
+			wantArguments: []string{"@name = 'l'", "@password = <redacted>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := statementError(tt.err, tt.statement, tt.args)
+
+			var statementErr *StatementError
+			if !errors.As(err, &statementErr) {
+				t.Fatalf("statementError() = %T, want *StatementError", err)
+			}
+			if statementErr.Statement != tt.wantStatement {
+				t.Fatalf("statementError() statement = %q, want %q", statementErr.Statement, tt.wantStatement)
+			}
+			if !reflect.DeepEqual(statementErr.Arguments, tt.wantArguments) {
+				t.Fatalf("statementError() arguments = %q, want %q", statementErr.Arguments, tt.wantArguments)
+			}
+			var sqlErr mssql.Error
+			if !errors.As(err, &sqlErr) || sqlErr.Number != 15025 {
+				t.Fatalf("statementError() does not unwrap to the server error")
+			}
+		})
+	}
+}
+
+func TestStatementErrorKeepsOtherErrors(t *testing.T) {
+	for _, err := range []error{nil, sql.ErrNoRows, errors.New("connection refused")} {
+		if got := statementError(err, "SELECT 1", nil); got != err {
+			t.Fatalf("statementError(%v) = %v, want the error unchanged", err, got)
+		}
+	}
+}
+
+func TestConnectorLogStatements(t *testing.T) {
+	tests := []struct {
+		name          string
+		logStatements bool
+		want          []map[string]interface{}
+	}{
+		{
+			name:          "enabled",
+			logStatements: true,
+			want: []map[string]interface{}{{
+				"@level":    "debug",
+				"@message":  "executed statement",
+				"@module":   "provider.sql",
+				"statement": "CREATE LOGIN [l] WITH PASSWORD = '<redacted>'",
+				"arguments": []interface{}{"@password = <redacted>"},
+				"host":      "localhost",
+				"database":  "",
+			}},
+		},
+		{
+			name:          "disabled",
+			logStatements: false,
+			want:          nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			ctx := tflogtest.RootLogger(context.Background(), &output)
+			ctx = tflog.NewSubsystem(ctx, LogSubsystem)
+
+			c := &Connector{Host: "localhost", Port: "1433", LogStatements: tt.logStatements, pool: newConnectionPool()}
+			if _, err := c.pool.get(context.Background(), c.poolKey(), func(context.Context) (*sql.DB, error) { return sql.OpenDB(&fakeConnector{}), nil }); err != nil {
+				t.Fatalf("get() error = %v", err)
+			}
+
+			err := c.ExecContext(ctx, "CREATE LOGIN [l] WITH PASSWORD = 'p@ss'", sql.Named("password", "p@ss"))
+			if err != nil {
+				t.Fatalf("ExecContext() error = %v", err)
+			}
+
+			got, err := tflogtest.MultilineJSONDecode(&output)
+			if err != nil {
+				t.Fatalf("MultilineJSONDecode() error = %v", err)
+			}
+			for _, entry := range got {
+				if _, ok := entry["duration_ms"]; !ok {
+					t.Fatalf("log entry %v has no duration_ms", entry)
+				}
+				delete(entry, "duration_ms")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("logs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
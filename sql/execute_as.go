@@ -0,0 +1,75 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/pkg/errors"
+)
+
+// ExecuteAs is the principal the statements of ExecContext are executed as,
+// with EXECUTE AS on the session of the statement.
+type ExecuteAs struct {
+	// Type is LOGIN or USER. Users are impersonated in the database of the
+	// connector.
+	Type string
+	Name string
+}
+
+// String returns the principal as written in the audit log, e.g. LOGIN deployer.
+func (e ExecuteAs) String() string {
+	return e.Type + " " + e.Name
+}
+
+// statement returns the EXECUTE AS statement. The name is a literal, as an
+// EXECUTE AS with parameters is executed by sp_executesql and reverted at its
+// end.
+func (e ExecuteAs) statement() string {
+	_, name := sqlLiteral(e.Name)
+	return fmt.Sprintf("EXECUTE AS %s = %s", e.Type, name)
+}
+
+// principalFunction returns the function naming the principal the session is
+// executed as.
+func (e ExecuteAs) principalFunction() string {
+	if e.Type == "USER" {
+		return "USER_NAME()"
+	}
+	return "SUSER_SNAME()"
+}
+
+// impersonate executes the session of conn as the principal and returns the
+// function reverting it. The function must be called before conn is closed; a
+// session that cannot be reverted is discarded rather than returned to the pool.
+func (c *Connector) impersonate(ctx context.Context, conn *sql.Conn) (func(), error) {
+	executeAs := *c.ExecuteAs
+	discard := func() {
+		_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	}
+
+	var principal string
+	err := conn.QueryRowContext(ctx, fmt.Sprintf("%s;\nSELECT %s", executeAs.statement(), executeAs.principalFunction())).Scan(&principal)
+	if err != nil {
+		// the session may run as the principal if only the SELECT failed
+		discard()
+		return nil, errors.Wrapf(err, "unable to execute as %s", executeAs)
+	}
+
+	revert := func() {
+		// the session is reverted on a new context, as it must be reverted even
+		// if the statement was cancelled
+		if _, err := conn.ExecContext(context.Background(), "REVERT"); err != nil {
+			tflog.Warn(ctx, fmt.Sprintf("unable to revert the execution as %s, closing the session", executeAs), map[string]interface{}{"error": err.Error()})
+			discard()
+		}
+	}
+	if !strings.EqualFold(principal, executeAs.Name) {
+		revert()
+		return nil, errors.Errorf("unable to execute as %s: the session runs as %s", executeAs, principal)
+	}
+	return revert, nil
+}
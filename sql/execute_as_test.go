@@ -0,0 +1,90 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	mssql "github.com/microsoft/go-mssqldb"
+)
+
+func TestExecuteAsStatement(t *testing.T) {
+	tests := []struct {
+		name      string
+		executeAs ExecuteAs
+		want      string
+	}{
+		{
+			name:      "login",
+			executeAs: ExecuteAs{Type: "LOGIN", Name: "deployer"},
+			want:      "EXECUTE AS LOGIN = N'deployer'",
+		},
+		{
+			name:      "quoted user",
+			executeAs: ExecuteAs{Type: "USER", Name: "o'brien"},
+			want:      "EXECUTE AS USER = N'o''brien'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.executeAs.statement(); got != tt.want {
+				t.Fatalf("statement() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnectorExecContextExecuteAs(t *testing.T) {
+	tests := []struct {
+		name           string
+		principal      string
+		execErrs       []error
+		wantStatements []string
+		wantErr        string
+	}{
+		{
+			name:           "impersonated",
+			principal:      "Deployer",
+			wantStatements: []string{"DROP LOGIN [l]", "REVERT"},
+		},
+		{
+			name:           "statement failed",
+			principal:      "deployer",
+			execErrs:       []error{mssql.Error{Number: 15151, Message: "Cannot drop the login 'l'."}},
+			wantStatements: []string{"DROP LOGIN [l]", "REVERT"},
+			wantErr:        "Cannot drop the login",
+		},
+		{
+			name:           "other principal",
+			principal:      "sa",
+			wantStatements: []string{"REVERT"},
+			wantErr:        "unable to execute as LOGIN deployer: the session runs as sa",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeConnector{execErrs: tt.execErrs, queryRow: []driver.Value{tt.principal}}
+			c := &Connector{Host: "localhost", Port: "1433", ExecuteAs: &ExecuteAs{Type: "LOGIN", Name: "deployer"}, pool: newConnectionPool()}
+			if _, err := c.pool.get(context.Background(), c.poolKey(), func(context.Context) (*sql.DB, error) { return sql.OpenDB(fake), nil }); err != nil {
+				t.Fatalf("get() error = %v", err)
+			}
+
+			err := c.ExecContext(context.Background(), "DROP LOGIN [l]")
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("ExecContext() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("ExecContext() error = %v, want %q", err, tt.wantErr)
+			}
+			// the session is always reverted after it was impersonated
+			if fake.queries != 1 || !reflect.DeepEqual(fake.statements, tt.wantStatements) {
+				t.Fatalf("%d queries and statements = %q, want 1 query and %q", fake.queries, fake.statements, tt.wantStatements)
+			}
+		})
+	}
+}
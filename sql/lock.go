@@ -0,0 +1,202 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultLockResource is the application lock taken by default.
+const DefaultLockResource = "terraform-provider-sqlserver"
+
+// ApplicationLock is the application lock in master taken around write
+// operations, so that concurrent applies to a server are serialized.
+type ApplicationLock struct {
+	Enabled  bool
+	Resource string
+	Timeout  time.Duration
+}
+
+// serverLocks are in-process locks per server and name.
+type serverLocks struct {
+	mu    sync.Mutex
+	locks map[string]processLock
+}
+
+func newServerLocks() *serverLocks {
+	return &serverLocks{locks: map[string]processLock{}}
+}
+
+func (l *serverLocks) get(server, name string) processLock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := server + "\x00" + name
+	lock, ok := l.locks[key]
+	if !ok {
+		lock = make(processLock, 1)
+		l.locks[key] = lock
+	}
+	return lock
+}
+
+// processLock is an in-process lock that can be waited for with a context.
+type processLock chan struct{}
+
+func (l processLock) lock() {
+	l <- struct{}{}
+}
+
+func (l processLock) unlock() {
+	<-l
+}
+
+// lockContext waits for the lock until ctx ends or the timeout, if positive,
+// is exceeded.
+func (l processLock) lockContext(ctx context.Context, timeout time.Duration) error {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-expired:
+		return errors.New(lockResults[-1])
+	}
+}
+
+// lockResourceGovernor serializes the resource governor operations of the
+// process on the server and returns the function releasing the lock.
+func (c *Connector) lockResourceGovernor() func() {
+	locks := c.locks
+	if locks == nil {
+		return func() {}
+	}
+	lock := locks.get(c.serverName(), "resource governor")
+	lock.lock()
+	return lock.unlock
+}
+
+// Lock takes the application lock of the connector, if enabled, and returns the
+// function releasing it. The lock is owned by a session of its own, so it is
+// held across the statements of an operation.
+func (c *Connector) Lock(ctx context.Context) (func(), error) {
+	if !c.ApplicationLock.Enabled || c.DryRun.Enabled || c.ReadOnly {
+		return func() {}, nil
+	}
+
+	// a single operation of the process waits for the lock, the others wait
+	// here without holding a connection, until ctx ends or the lock times out
+	timeout := c.ApplicationLock.Timeout
+	unlockProcess := func() {}
+	if c.locks != nil {
+		lock := c.locks.get(c.serverName(), "application lock")
+		start := time.Now()
+		if err := lock.lockContext(ctx, timeout); err != nil {
+			return nil, errors.Wrapf(err, "unable to take application lock %s", c.lockResource())
+		}
+		unlockProcess = lock.unlock
+		if timeout > 0 {
+			// the wait of the server lock is bounded by what is left of the timeout
+			timeout -= time.Since(start)
+			if timeout < time.Millisecond {
+				timeout = time.Millisecond
+			}
+		}
+	}
+
+	unlockServer, err := c.lockServer(ctx, timeout)
+	if err != nil {
+		unlockProcess()
+		return nil, err
+	}
+	return func() {
+		unlockServer()
+		unlockProcess()
+	}, nil
+}
+
+// lockResource returns the name of the application lock.
+func (c *Connector) lockResource() string {
+	if c.ApplicationLock.Resource == "" {
+		return DefaultLockResource
+	}
+	return c.ApplicationLock.Resource
+}
+
+func (c *Connector) lockServer(ctx context.Context, lockTimeout time.Duration) (func(), error) {
+	// the lock is taken on connections of their own, so waiting for the lock
+	// does not use up the connections of the statements
+	lockConnector := c.inDatabase("master")
+	var db *sql.DB
+	var err error
+	if c.pool != nil {
+		db, err = c.pool.get(ctx, lockConnector.poolKey()+"/lock", lockConnector.open)
+	} else {
+		db, err = lockConnector.open(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		lockConnector.release(db)
+		return nil, err
+	}
+	done := func(discard bool) {
+		if discard {
+			// the session may still own the lock, so it is not reused
+			_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+		lockConnector.release(db)
+	}
+
+	resource := c.lockResource()
+	timeout := lockTimeout.Milliseconds()
+	if lockTimeout <= 0 {
+		timeout = -1
+	}
+
+	var result int
+	err = conn.QueryRowContext(ctx, `
+		DECLARE @result int
+		EXEC @result = sp_getapplock @Resource = @resource, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = @timeout
+		SELECT @result`,
+		sql.Named("resource", resource), sql.Named("timeout", timeout)).Scan(&result)
+	if err != nil {
+		done(true)
+		return nil, errors.Wrapf(err, "unable to take application lock %s", resource)
+	}
+	if result < 0 {
+		done(false)
+		return nil, errors.Errorf("unable to take application lock %s: %s", resource, lockResults[result])
+	}
+
+	return func() {
+		// the lock is released on a new context, as it must be released even if
+		// the operation was cancelled
+		_, err := conn.ExecContext(context.Background(), "EXEC sp_releaseapplock @Resource = @resource, @LockOwner = 'Session'",
+			sql.Named("resource", resource))
+		done(err != nil)
+	}, nil
+}
+
+// lockResults are the errors returned by sp_getapplock.
+var lockResults = map[int]string{
+	-1:   "timed out",
+	-2:   "cancelled",
+	-3:   "chosen as deadlock victim",
+	-999: "invalid parameter or call error",
+}
@@ -0,0 +1,182 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newLockConnector returns a connector with an application lock, whose lock
+// connections are made by fake.
+func newLockConnector(t *testing.T, fake *fakeConnector) *Connector {
+	t.Helper()
+
+	c := &Connector{
+		Host:            "localhost",
+		Port:            "1433",
+		ApplicationLock: ApplicationLock{Enabled: true, Timeout: time.Second},
+		pool:            newConnectionPool(),
+		locks:           newServerLocks(),
+	}
+	lockConnector := *c
+	lockConnector.Database = "master"
+	if _, err := c.pool.get(context.Background(), lockConnector.poolKey()+"/lock", func(context.Context) (*sql.DB, error) { return sql.OpenDB(fake), nil }); err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	return c
+}
+
+func TestConnectorLock(t *testing.T) {
+	tests := []struct {
+		name        string
+		queryValues []int64
+		wantErr     string
+	}{
+		{
+			name:        "granted",
+			queryValues: []int64{0},
+		},
+		{
+			name:        "granted after waiting",
+			queryValues: []int64{1},
+		},
+		{
+			name:        "timed out",
+			queryValues: []int64{-1},
+			wantErr:     "unable to take application lock terraform-provider-sqlserver: timed out",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeConnector{queryValues: tt.queryValues}
+			c := newLockConnector(t, fake)
+
+			unlock, err := c.Lock(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Lock() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Lock() error = %v", err)
+			}
+			unlock()
+
+			if len(fake.statements) != 1 || !strings.HasPrefix(fake.statements[0], "EXEC sp_releaseapplock") {
+				t.Fatalf("statements = %q, want the lock released", fake.statements)
+			}
+		})
+	}
+}
+
+func TestConnectorLockSerializesOperations(t *testing.T) {
+	c := newLockConnector(t, &fakeConnector{})
+
+	unlock, err := c.Lock(context.Background())
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	locked := make(chan struct{})
+	go func() {
+		unlock, err := c.Lock(context.Background())
+		if err == nil {
+			unlock()
+		}
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatalf("Lock() returned while the lock is held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatalf("Lock() did not return after the lock was released")
+	}
+}
+
+func TestConnectorLockWaitEnds(t *testing.T) {
+	tests := []struct {
+		name        string
+		lockTimeout time.Duration
+		cancelAfter time.Duration
+		wantErr     string
+	}{
+		{
+			name:        "cancelled",
+			lockTimeout: time.Minute,
+			cancelAfter: 20 * time.Millisecond,
+			wantErr:     "unable to take application lock terraform-provider-sqlserver: context deadline exceeded",
+		},
+		{
+			name:        "timed out",
+			lockTimeout: 20 * time.Millisecond,
+			wantErr:     "unable to take application lock terraform-provider-sqlserver: timed out",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeConnector{}
+			c := newLockConnector(t, fake)
+			c.ApplicationLock.Timeout = tt.lockTimeout
+
+			unlock, err := c.Lock(context.Background())
+			if err != nil {
+				t.Fatalf("Lock() error = %v", err)
+			}
+			defer unlock()
+
+			ctx := context.Background()
+			if tt.cancelAfter > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.cancelAfter)
+				defer cancel()
+			}
+
+			locked := make(chan error, 1)
+			go func() {
+				unlock, err := c.Lock(ctx)
+				if err == nil {
+					unlock()
+				}
+				locked <- err
+			}()
+
+			select {
+			case err := <-locked:
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Lock() error = %v, want %q", err, tt.wantErr)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("Lock() waited past its deadline")
+			}
+			if fake.queries != 1 {
+				t.Fatalf("%d lock queries, want only the one of the held lock", fake.queries)
+			}
+		})
+	}
+}
+
+func TestConnectorLockDisabled(t *testing.T) {
+	for _, c := range []*Connector{
+		{Host: "sql1.invalid", Port: "1433"},
+		{Host: "sql1.invalid", Port: "1433", ApplicationLock: ApplicationLock{Enabled: true}, DryRun: DryRun{Enabled: true}},
+		{Host: "sql1.invalid", Port: "1433", ApplicationLock: ApplicationLock{Enabled: true}, ReadOnly: true},
+	} {
+		// the host does not resolve, so taking the lock would fail
+		unlock, err := c.Lock(context.Background())
+		if err != nil {
+			t.Fatalf("Lock() error = %v", err)
+		}
+		unlock()
+	}
+}
@@ -37,13 +37,10 @@ func (c *Connector) CreateLogin(ctx context.Context, name, password, sourceType
             END
           EXEC (@sql)`
 
-	database := "master"
-	return c.
-		setDatabase(&database).
-		ExecContext(ctx, cmd,
-			sql.Named("name", name),
-			sql.Named("password", password),
-			sql.Named("sourceType", sourceType))
+	return c.inDatabase("master").ExecContext(ctx, cmd,
+		sql.Named("name", name),
+		sql.Named("password", password),
+		sql.Named("sourceType", sourceType))
 }
 
 func (c *Connector) UpdateLogin(ctx context.Context, name string, password string) error {
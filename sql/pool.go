@@ -0,0 +1,90 @@
+package sql
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+	"sync"
+)
+
+// connectionPool keeps one *sql.DB per server, database and credential so that
+// all connectors created by a provider share their underlying connections.
+type connectionPool struct {
+	mu      sync.Mutex
+	entries map[string]*poolEntry
+}
+
+type poolEntry struct {
+	// opening holds a token while the database of the entry is opened, so that
+	// waiting callers can give up when their context ends.
+	opening chan struct{}
+	db      *sql.DB
+}
+
+func newConnectionPool() *connectionPool {
+	return &connectionPool{entries: make(map[string]*poolEntry)}
+}
+
+// get returns the pooled database for key, calling open the first time the key
+// is requested or after a previous open failed. Callers waiting for another
+// caller to open the database return when ctx ends.
+func (p *connectionPool) get(ctx context.Context, key string, open func(context.Context) (*sql.DB, error)) (*sql.DB, error) {
+	p.mu.Lock()
+	entry, ok := p.entries[key]
+	if !ok {
+		entry = &poolEntry{opening: make(chan struct{}, 1)}
+		p.entries[key] = entry
+	}
+	p.mu.Unlock()
+
+	select {
+	case entry.opening <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-entry.opening }()
+
+	if entry.db != nil {
+		return entry.db, nil
+	}
+
+	db, err := open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entry.db = db
+
+	return db, nil
+}
+
+// poolKey identifies the server, database, session and credential of the connector. Secrets
+// are hashed so they are not kept around as part of the key.
+func (c *Connector) poolKey() string {
+	credential := []string{}
+	if c.Login != nil {
+		credential = append(credential, "login", c.Login.Username, c.Login.Password)
+	}
+	if c.AzureLogin != nil {
+		credential = append(credential, "azure", c.AzureLogin.TenantID, c.AzureLogin.ClientID, c.AzureLogin.ClientSecret,
+			c.AzureLogin.ClientCertificatePath, c.AzureLogin.ClientCertificate, c.AzureLogin.ClientCertificatePassword)
+	}
+	if c.FedauthMSI != nil {
+		credential = append(credential, "msi", c.FedauthMSI.UserID)
+	}
+	if c.WorkloadIdentity != nil {
+		credential = append(credential, "workload", c.WorkloadIdentity.TenantID, c.WorkloadIdentity.ClientID, c.WorkloadIdentity.TokenFilePath, c.WorkloadIdentity.AuthorityHost)
+	}
+	if c.AccessToken != nil {
+		credential = append(credential, "token", c.AccessToken.Token)
+		credential = append(credential, c.AccessToken.Command...)
+	}
+	if len(credential) == 0 {
+		credential = append(credential, "default")
+	}
+
+	hash := sha256.Sum256([]byte(strings.Join(credential, "\x00")))
+
+	return strings.Join([]string{c.Host, c.Port, c.Instance, c.Database, c.Session.ApplicationName, c.Session.WorkstationID, hex.EncodeToString(hash[:])}, "/")
+}
@@ -0,0 +1,132 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type nopConnector struct{}
+
+func (nopConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, errors.New("not connected")
+}
+
+func (nopConnector) Driver() driver.Driver {
+	return nil
+}
+
+func TestConnectionPoolReusesDatabase(t *testing.T) {
+	pool := newConnectionPool()
+	opened := 0
+	open := func(context.Context) (*sql.DB, error) {
+		opened++
+		return sql.OpenDB(nopConnector{}), nil
+	}
+
+	first, err := pool.get(context.Background(), "key", open)
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	second, err := pool.get(context.Background(), "key", open)
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+
+	if first != second {
+		t.Fatalf("get() returned different databases for the same key")
+	}
+	if opened != 1 {
+		t.Fatalf("open called %d times, want 1", opened)
+	}
+}
+
+func TestConnectionPoolRetriesFailedOpen(t *testing.T) {
+	pool := newConnectionPool()
+	failing := func(context.Context) (*sql.DB, error) {
+		return nil, errors.New("connection refused")
+	}
+	if _, err := pool.get(context.Background(), "key", failing); err == nil {
+		t.Fatalf("get() expected error")
+	}
+
+	db, err := pool.get(context.Background(), "key", func(context.Context) (*sql.DB, error) {
+		return sql.OpenDB(nopConnector{}), nil
+	})
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	if db == nil {
+		t.Fatalf("get() returned nil database")
+	}
+}
+
+func TestConnectorPoolKey(t *testing.T) {
+	base := Connector{Host: "localhost", Port: "1433", Database: "master", Login: &LoginUser{Username: "sa", Password: "secret"}}
+
+	tests := []struct {
+		name  string
+		other Connector
+		same  bool
+	}{
+		{
+			name:  "same server, database and login",
+			other: Connector{Host: "localhost", Port: "1433", Database: "master", Login: &LoginUser{Username: "sa", Password: "secret"}},
+			same:  true,
+		},
+		{
+			name:  "different database",
+			other: Connector{Host: "localhost", Port: "1433", Database: "tempdb", Login: &LoginUser{Username: "sa", Password: "secret"}},
+			same:  false,
+		},
+		{
+			name:  "different password",
+			other: Connector{Host: "localhost", Port: "1433", Database: "master", Login: &LoginUser{Username: "sa", Password: "other"}},
+			same:  false,
+		},
+		{
+			name:  "different port",
+			other: Connector{Host: "localhost", Port: "1434", Database: "master", Login: &LoginUser{Username: "sa", Password: "secret"}},
+			same:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := base.poolKey() == tt.other.poolKey()
+			if got != tt.same {
+				t.Fatalf("poolKey() equal = %v, want %v", got, tt.same)
+			}
+		})
+	}
+
+	if key := base.poolKey(); len(key) == 0 || strings.Contains(key, "secret") {
+		t.Fatalf("poolKey() = %q must not contain the password", key)
+	}
+}
+
+func TestConnectionPoolGetCancelled(t *testing.T) {
+	pool := newConnectionPool()
+	opening := make(chan struct{})
+	release := make(chan struct{})
+	go pool.get(context.Background(), "key", func(context.Context) (*sql.DB, error) {
+		close(opening)
+		<-release
+		return nil, errors.New("connection refused")
+	})
+	defer close(release)
+	<-opening
+
+	// a caller waiting for the open gives up with its context
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := pool.get(ctx, "key", func(context.Context) (*sql.DB, error) {
+		return sql.OpenDB(nopConnector{}), nil
+	}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("get() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,89 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"terraform-provider-sqlserver/sqlserver/model"
+)
+
+// GetPrincipal returns the principal of the connector with the server
+// permissions it holds, and per database permission the online databases it can
+// access without holding it. Permissions that do not exist on the server, like
+// server permissions on Azure SQL Database, are not held.
+func (c *Connector) GetPrincipal(ctx context.Context, serverPermissions, databasePermissions []string) (*model.Principal, error) {
+	principal := model.Principal{
+		ServerPermissions:          map[string]bool{},
+		MissingDatabasePermissions: map[string][]string{},
+	}
+	err := c.QueryRowContext(ctx, "SELECT SUSER_SNAME()",
+		func(r *sql.Row) error {
+			return r.Scan(&principal.Name)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(serverPermissions) > 0 {
+		values, args := permissionValues(serverPermissions)
+		err = c.QueryContext(ctx,
+			`SELECT p.permission, ISNULL(HAS_PERMS_BY_NAME(NULL, NULL, p.permission), 0)
+			FROM (VALUES `+values+`) p(permission)`,
+			func(r *sql.Rows) error {
+				for r.Next() {
+					var permission string
+					var held bool
+					if err := r.Scan(&permission, &held); err != nil {
+						return err
+					}
+					principal.ServerPermissions[permission] = held
+				}
+				return r.Err()
+			},
+			args...,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, permission := range databasePermissions {
+		err = c.QueryContext(ctx,
+			`SELECT name FROM sys.databases
+			WHERE state = 0 AND HAS_DBACCESS(name) = 1
+			AND ISNULL(HAS_PERMS_BY_NAME(name, 'DATABASE', @permission), 0) = 0
+			ORDER BY name`,
+			func(r *sql.Rows) error {
+				for r.Next() {
+					var database string
+					if err := r.Scan(&database); err != nil {
+						return err
+					}
+					principal.MissingDatabasePermissions[permission] = append(principal.MissingDatabasePermissions[permission], database)
+				}
+				return r.Err()
+			},
+			sql.Named("permission", permission),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &principal, nil
+}
+
+// permissionValues returns the server permissions as a VALUES list of
+// parameters and its arguments.
+func permissionValues(permissions []string) (string, []interface{}) {
+	values := make([]string, 0, len(permissions))
+	args := make([]interface{}, 0, len(permissions))
+	for i, permission := range permissions {
+		values = append(values, fmt.Sprintf("(@p%d)", i))
+		args = append(args, sql.Named(fmt.Sprintf("p%d", i), permission))
+	}
+	return strings.Join(values, ", "), args
+}
@@ -0,0 +1,46 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrReadOnly is returned for statements a read-only connector refuses.
+var ErrReadOnly = errors.New("connector is read-only")
+
+// queryer runs queries on a database or in a transaction.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// queryer returns db, or for read-only connectors a transaction that is rolled
+// back by done, so queries cannot change anything even if they try to.
+func (c *Connector) queryer(ctx context.Context, db *sql.DB) (q queryer, done func(), err error) {
+	if !c.ReadOnly {
+		return db, func() {}, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tx, func() { _ = tx.Rollback() }, nil
+}
+
+// refuseWrite returns ErrReadOnly for the statements of ExecContext of a
+// read-only connector, which are all statements not returning rows.
+func (c *Connector) refuseWrite(statement string) error {
+	if !c.ReadOnly {
+		return nil
+	}
+
+	statement = redactStatement(strings.TrimSpace(statement))
+	if i := strings.IndexByte(statement, '\n'); i >= 0 {
+		statement = statement[:i] + " ..."
+	}
+	return errors.Wrapf(ErrReadOnly, "refusing to execute %q", statement)
+}
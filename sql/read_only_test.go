@@ -0,0 +1,64 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestConnectorReadOnly(t *testing.T) {
+	tests := []struct {
+		name          string
+		readOnly      bool
+		wantErr       error
+		wantExecs     int
+		wantRollbacks int
+	}{
+		{
+			name:          "read-only",
+			readOnly:      true,
+			wantErr:       ErrReadOnly,
+			wantRollbacks: 2,
+		},
+		{
+			name:      "read-write",
+			wantExecs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeConnector{}
+			c := &Connector{Host: "sql1", Port: "1433", ReadOnly: tt.readOnly, pool: newConnectionPool()}
+			if _, err := c.pool.get(context.Background(), c.poolKey(), func(context.Context) (*sql.DB, error) { return sql.OpenDB(fake), nil }); err != nil {
+				t.Fatalf("get() error = %v", err)
+			}
+
+			if err := c.ExecContext(context.Background(), "DROP LOGIN [l]"); errors.Cause(err) != tt.wantErr {
+				t.Fatalf("ExecContext() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var value int
+			err := c.QueryRowContext(context.Background(), "SELECT 1", func(row *sql.Row) error {
+				return row.Scan(&value)
+			})
+			if err != nil || value != 1 {
+				t.Fatalf("QueryRowContext() = %d, %v, want 1", value, err)
+			}
+			err = c.QueryContext(context.Background(), "SELECT 1", func(rows *sql.Rows) error {
+				for rows.Next() {
+				}
+				return rows.Err()
+			})
+			if err != nil {
+				t.Fatalf("QueryContext() error = %v", err)
+			}
+
+			if fake.execs != tt.wantExecs || fake.rollbacks != tt.wantRollbacks || fake.queries != 2 {
+				t.Fatalf("executed %d statements, %d queries and %d rollbacks, want %d, 2 and %d", fake.execs, fake.queries, fake.rollbacks, tt.wantExecs, tt.wantRollbacks)
+			}
+		})
+	}
+}
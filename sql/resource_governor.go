@@ -53,10 +53,12 @@ func (c *Connector) GetResourceGovernor(ctx context.Context) (*model.ResourceGov
 }
 
 func (c *Connector) EnableResourceGovernor(ctx context.Context, classifierFunction string) error {
+	defer c.lockResourceGovernor()()
+
 	return c.withSessionDrainRetry(
 		ctx,
 		func(ctx context.Context) error {
-			return c.killSessionsByWhereClause(ctx, nonCurrentUserSessionsWhereClause)
+			return c.killSessionsByWhereClause(ctx, c.otherSessionsWhereClause())
 		},
 		func(ctx context.Context) error {
 			// Set classifier function if provided
@@ -74,10 +76,12 @@ func (c *Connector) EnableResourceGovernor(ctx context.Context, classifierFuncti
 }
 
 func (c *Connector) DisableResourceGovernor(ctx context.Context) error {
+	defer c.lockResourceGovernor()()
+
 	return c.withSessionDrainRetry(
 		ctx,
 		func(ctx context.Context) error {
-			return c.killSessionsByWhereClause(ctx, nonCurrentUserSessionsWhereClause)
+			return c.killSessionsByWhereClause(ctx, c.otherSessionsWhereClause())
 		},
 		func(ctx context.Context) error {
 			// Clear classifier function
@@ -92,10 +96,12 @@ func (c *Connector) DisableResourceGovernor(ctx context.Context) error {
 }
 
 func (c *Connector) UpdateResourceGovernor(ctx context.Context, rg *model.ResourceGovernor) error {
+	defer c.lockResourceGovernor()()
+
 	return c.withSessionDrainRetry(
 		ctx,
 		func(ctx context.Context) error {
-			return c.killSessionsByWhereClause(ctx, nonCurrentUserSessionsWhereClause)
+			return c.killSessionsByWhereClause(ctx, c.otherSessionsWhereClause())
 		},
 		func(ctx context.Context) error {
 			// Set classifier function
@@ -197,7 +203,7 @@ func (c *Connector) killSessionsByWhereClause(ctx context.Context, whereClause s
 }
 
 func (c *Connector) killWorkloadGroupSessions(ctx context.Context, workloadGroupName string) error {
-	return c.killSessionsByWhereClause(ctx, nonCurrentUserSessionsWhereClause+` AND s.group_id IN (
+	return c.killSessionsByWhereClause(ctx, c.otherSessionsWhereClause()+` AND s.group_id IN (
 		SELECT g.group_id
 		FROM sys.resource_governor_workload_groups g
 		WHERE g.name = @workloadGroupName
@@ -205,10 +211,23 @@ func (c *Connector) killWorkloadGroupSessions(ctx context.Context, workloadGroup
 }
 
 func (c *Connector) killResourcePoolSessions(ctx context.Context, resourcePoolName string) error {
-	return c.killSessionsByWhereClause(ctx, nonCurrentUserSessionsWhereClause+` AND s.group_id IN (
+	return c.killSessionsByWhereClause(ctx, c.otherSessionsWhereClause()+` AND s.group_id IN (
 		SELECT g.group_id
 		FROM sys.resource_governor_workload_groups g
 		INNER JOIN sys.resource_governor_resource_pools p ON p.pool_id = g.pool_id
 		WHERE p.name = @resourcePoolName
 	)`, sql.Named("resourcePoolName", resourcePoolName))
 }
+
+// otherSessionsWhereClause returns the filter of the sessions that may be
+// killed. With an application name, the sessions of the provider, including
+// those of concurrent runs, are left alone. Without one, the sessions use the
+// name of the driver, which is shared by unrelated applications, so only the
+// current session is left alone; the provider requires an application name.
+func (c *Connector) otherSessionsWhereClause() string {
+	if c.Session.ApplicationName == "" {
+		return nonCurrentUserSessionsWhereClause
+	}
+	_, name := sqlLiteral(c.Session.ApplicationName)
+	return nonCurrentUserSessionsWhereClause + " AND ISNULL(s.program_name, N'') <> " + name
+}
@@ -37,3 +37,35 @@ func TestIsIgnorableInactiveProcessIDError(t *testing.T) {
 		})
 	}
 }
+
+func TestConnectorOtherSessionsWhereClause(t *testing.T) {
+	tests := []struct {
+		name            string
+		applicationName string
+		want            string
+	}{
+		{
+			name:            "application name",
+			applicationName: "terraform-provider-sqlserver",
+			want:            `s.is_user_process = 1 AND s.session_id <> @@SPID AND ISNULL(s.program_name, N'') <> N'terraform-provider-sqlserver'`,
+		},
+		{
+			name:            "quoted application name",
+			applicationName: "team's terraform",
+			want:            `s.is_user_process = 1 AND s.session_id <> @@SPID AND ISNULL(s.program_name, N'') <> N'team''s terraform'`,
+		},
+		{
+			name: "no application name",
+			want: `s.is_user_process = 1 AND s.session_id <> @@SPID`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Connector{Session: Session{ApplicationName: tt.applicationName}}
+			if got := c.otherSessionsWhereClause(); got != tt.want {
+				t.Fatalf("otherSessionsWhereClause() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -47,6 +47,8 @@ func (c *Connector) GetResourcePool(ctx context.Context, name string) (*model.Re
 }
 
 func (c *Connector) CreateResourcePool(ctx context.Context, pool *model.ResourcePool) error {
+	defer c.lockResourceGovernor()()
+
 	cmd := fmt.Sprintf(`CREATE RESOURCE POOL %s WITH (
 		MIN_CPU_PERCENT = %d,
 		MAX_CPU_PERCENT = %d,
@@ -75,6 +77,8 @@ func (c *Connector) CreateResourcePool(ctx context.Context, pool *model.Resource
 }
 
 func (c *Connector) UpdateResourcePool(ctx context.Context, pool *model.ResourcePool) error {
+	defer c.lockResourceGovernor()()
+
 	return c.withSessionDrainRetry(
 		ctx,
 		func(ctx context.Context) error {
@@ -111,6 +115,8 @@ func (c *Connector) UpdateResourcePool(ctx context.Context, pool *model.Resource
 }
 
 func (c *Connector) DeleteResourcePool(ctx context.Context, name string) error {
+	defer c.lockResourceGovernor()()
+
 	return c.withSessionDrainRetry(
 		ctx,
 		func(ctx context.Context) error {
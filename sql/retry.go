@@ -0,0 +1,111 @@
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/pkg/errors"
+)
+
+const retryBaseBackoff = 250 * time.Millisecond
+
+// transientErrorNumbers are the SQL Server errors that are resolved by trying
+// again, mostly caused by Azure SQL failovers, throttling and deadlocks.
+var transientErrorNumbers = map[int32]string{
+	233:   "connection closed by the server",
+	1205:  "deadlock victim",
+	4060:  "database not available",
+	4221:  "login to read-secondary failed during replica change",
+	10053: "connection aborted",
+	10054: "connection reset",
+	10060: "connection timed out",
+	10928: "resource limit reached",
+	10929: "resource limit reached",
+	40143: "connection terminated",
+	40197: "service error while processing the request",
+	40501: "service is busy",
+	40540: "service error while processing the request",
+	40613: "database not available",
+	49918: "not enough resources to process the request",
+	49919: "too many operations in progress",
+	49920: "too many operations in progress",
+}
+
+// Retry configures the retries of transient errors. Statements are tried at
+// most MaxAttempts times, connects are retried until the timeout of the
+// connector. The delay between attempts doubles up to MaxBackoff.
+type Retry struct {
+	MaxAttempts int           `json:"max_attempts,omitempty"`
+	MaxBackoff  time.Duration `json:"max_backoff,omitempty"`
+}
+
+// do calls fn until it succeeds, returns an error that is not transient or the
+// attempts are exhausted.
+func (r Retry) do(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= r.MaxAttempts || !isTransient(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(r.backoff(attempt)):
+		}
+	}
+}
+
+// backoff returns the delay before the next attempt, exponential in the number
+// of attempts with jitter, so concurrent resources do not retry in lockstep.
+func (r Retry) backoff(attempt int) time.Duration {
+	backoff := retryBaseBackoff
+	for i := 1; i < attempt && (r.MaxBackoff <= 0 || backoff < r.MaxBackoff); i++ {
+		backoff *= 2
+	}
+	if r.MaxBackoff > 0 && backoff > r.MaxBackoff {
+		backoff = r.MaxBackoff
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// isTransient reports whether err is a SQL Server error or a network error that
+// may succeed when tried again.
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var sqlErr mssql.Error
+	if errors.As(err, &sqlErr) {
+		if _, ok := transientErrorNumbers[sqlErr.Number]; ok {
+			return true
+		}
+		for _, e := range sqlErr.All {
+			if _, ok := transientErrorNumbers[e.Number]; ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNABORTED) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var streamErr mssql.StreamError
+	return errors.As(err, &streamErr)
+}
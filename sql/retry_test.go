@@ -0,0 +1,451 @@
+package sql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/pkg/errors"
+)
+
+// fakeConnector returns connectErrs from the first connects and execErrs from
+// the first statements, after which connects and statements succeed. Queries
+// return queryRow, if set, or else a single row with the first of queryValues
+// or the value 1. The executed statements are recorded.
+type fakeConnector struct {
+	mu          sync.Mutex
+	connectErrs []error
+	execErrs    []error
+	connects    int
+	execs       int
+	queries     int
+	begins      int
+	rollbacks   int
+	statements  []string
+	queryValues []int64
+	queryRow    []driver.Value
+}
+
+func (f *fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.connects++
+	if len(f.connectErrs) > 0 {
+		err := f.connectErrs[0]
+		f.connectErrs = f.connectErrs[1:]
+		return nil, err
+	}
+	return &fakeConn{connector: f}, nil
+}
+
+func (f *fakeConnector) Driver() driver.Driver {
+	return nil
+}
+
+type fakeConn struct {
+	connector *fakeConnector
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	f := c.connector
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.begins++
+	return &fakeTx{connector: f}, nil
+}
+
+func (c *fakeConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	f := c.connector
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.queries++
+	if f.queryRow != nil {
+		return &fakeRows{values: f.queryRow}, nil
+	}
+	value := int64(1)
+	if len(f.queryValues) > 0 {
+		value = f.queryValues[0]
+		f.queryValues = f.queryValues[1:]
+	}
+	return &fakeRows{values: []driver.Value{value}}, nil
+}
+
+type fakeTx struct {
+	connector *fakeConnector
+}
+
+func (tx *fakeTx) Commit() error {
+	return errors.New("not supported")
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.connector.mu.Lock()
+	defer tx.connector.mu.Unlock()
+
+	tx.connector.rollbacks++
+	return nil
+}
+
+type fakeRows struct {
+	values []driver.Value
+	done   bool
+}
+
+func (r *fakeRows) Columns() []string {
+	return make([]string, len(r.values))
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.values)
+	return nil
+}
+
+func (c *fakeConn) Ping(context.Context) error {
+	return nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, statement string, _ []driver.NamedValue) (driver.Result, error) {
+	f := c.connector
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.execs++
+	f.statements = append(f.statements, statement)
+	if len(f.execErrs) > 0 {
+		err := f.execErrs[0]
+		f.execErrs = f.execErrs[1:]
+		return nil, err
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "database not available",
+			err:  mssql.Error{Number: 40613, Message: "Database is not currently available."},
+			want: true,
+		},
+		{
+			name: "wrapped deadlock",
+			err:  errors.Wrap(mssql.Error{Number: 1205}, "unable to create login"),
+			want: true,
+		},
+		{
+			name: "transient error in all",
+			err:  mssql.Error{Number: 3998, All: []mssql.Error{{Number: 1205}, {Number: 3998}}},
+			want: true,
+		},
+		{
+			name: "login failed",
+			err:  mssql.Error{Number: 18456, Message: "Login failed for user 'sa'."},
+			want: false,
+		},
+		{
+			name: "syntax error",
+			err:  mssql.Error{Number: 102},
+			want: false,
+		},
+		{
+			name: "bad connection",
+			err:  mssql.RetryableError{},
+			want: true,
+		},
+		{
+			name: "connection reset",
+			err:  &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET},
+			want: true,
+		},
+		{
+			name: "canceled",
+			err:  errors.Wrap(context.Canceled, "in ping"),
+			want: false,
+		},
+		{
+			name: "token error",
+			err:  errors.New("AuthenticationFailedError"),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransient(tt.err); got != tt.want {
+				t.Fatalf("isTransient() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		retry   Retry
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{
+			name:    "first attempt",
+			retry:   Retry{MaxBackoff: time.Minute},
+			attempt: 1,
+			min:     125 * time.Millisecond,
+			max:     250 * time.Millisecond,
+		},
+		{
+			name:    "grows exponentially",
+			retry:   Retry{MaxBackoff: time.Minute},
+			attempt: 4,
+			min:     time.Second,
+			max:     2 * time.Second,
+		},
+		{
+			name:    "capped",
+			retry:   Retry{MaxBackoff: 3 * time.Second},
+			attempt: 50,
+			min:     1500 * time.Millisecond,
+			max:     3 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				if backoff := tt.retry.backoff(tt.attempt); backoff < tt.min || backoff > tt.max {
+					t.Fatalf("backoff() = %s, want between %s and %s", backoff, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestConnectorExecContextRetry(t *testing.T) {
+	unavailable := mssql.Error{Number: 40613}
+
+	tests := []struct {
+		name      string
+		execErrs  []error
+		wantExecs int
+		wantErr   bool
+	}{
+		{
+			name:      "succeeds after failover",
+			execErrs:  []error{unavailable, unavailable},
+			wantExecs: 3,
+		},
+		{
+			name:      "attempts exhausted",
+			execErrs:  []error{unavailable, unavailable, unavailable},
+			wantExecs: 3,
+			wantErr:   true,
+		},
+		{
+			name:      "permanent error",
+			execErrs:  []error{mssql.Error{Number: 15025, Message: "The server principal already exists."}},
+			wantExecs: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeConnector{execErrs: tt.execErrs}
+			c := &Connector{
+				Host:  "localhost",
+				Port:  "1433",
+				Retry: Retry{MaxAttempts: 3, MaxBackoff: time.Millisecond},
+				pool:  newConnectionPool(),
+			}
+			if _, err := c.pool.get(context.Background(), c.poolKey(), func(context.Context) (*sql.DB, error) { return sql.OpenDB(fake), nil }); err != nil {
+				t.Fatalf("get() error = %v", err)
+			}
+
+			err := c.ExecContext(context.Background(), "CREATE LOGIN [l] WITH PASSWORD = 'p'")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExecContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if fake.execs != tt.wantExecs {
+				t.Fatalf("ExecContext() executed %d times, want %d", fake.execs, tt.wantExecs)
+			}
+		})
+	}
+}
+
+func TestConnectLoop(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+
+	tests := []struct {
+		name         string
+		connectErrs  []error
+		timeout      time.Duration
+		cancelAfter  time.Duration
+		wantConnects int
+		wantErr      bool
+	}{
+		{
+			name:         "server starting",
+			connectErrs:  []error{refused, refused},
+			timeout:      time.Minute,
+			wantConnects: 3,
+		},
+		{
+			name:         "login failed",
+			connectErrs:  []error{mssql.Error{Number: 18456}},
+			timeout:      time.Minute,
+			wantConnects: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "timeout",
+			connectErrs:  []error{refused, refused, refused, refused, refused, refused, refused, refused},
+			timeout:      10 * time.Millisecond,
+			wantConnects: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "no timeout",
+			connectErrs:  []error{refused},
+			wantConnects: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "cancelled",
+			connectErrs:  []error{refused, refused, refused, refused, refused, refused, refused, refused},
+			timeout:      time.Minute,
+			cancelAfter:  10 * time.Millisecond,
+			wantConnects: 1,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeConnector{connectErrs: tt.connectErrs}
+			ctx := context.Background()
+			if tt.cancelAfter > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.cancelAfter)
+				defer cancel()
+			}
+
+			db, err := connectLoop(ctx, fake, tt.timeout, Retry{MaxBackoff: 20 * time.Millisecond})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("connectLoop() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if db != nil {
+				db.Close()
+			}
+			if fake.connects < tt.wantConnects || (!tt.wantErr && fake.connects != tt.wantConnects) {
+				t.Fatalf("connectLoop() connected %d times, want %d", fake.connects, tt.wantConnects)
+			}
+		})
+	}
+}
+
+// TestConnectLoopLogsRetries checks that the failed connects are logged through
+// tflog, so the secrets masked by the provider stay hidden.
+func TestConnectLoopLogsRetries(t *testing.T) {
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+	ctx = tflog.MaskLogStrings(ctx, "secret-host")
+
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	fake := &fakeConnector{connectErrs: []error{errors.Wrap(refused, "secret-host")}}
+	db, err := connectLoop(ctx, fake, time.Minute, Retry{MaxBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatalf("connectLoop() error = %v", err)
+	}
+	db.Close()
+
+	logs := output.String()
+	if !strings.Contains(logs, "failed to connect to database") {
+		t.Fatalf("logs do not contain the failed connect:\n%s", logs)
+	}
+	if strings.Contains(logs, "secret-host") {
+		t.Fatalf("logs contain the masked secret:\n%s", logs)
+	}
+}
+
+func TestConnectorConnectTimeout(t *testing.T) {
+	timeouts := Timeouts{Create: 30 * time.Minute, Read: 5 * time.Minute, Update: 20 * time.Minute, Delete: 10 * time.Minute}
+
+	tests := []struct {
+		name      string
+		connector Connector
+		operation string
+		want      time.Duration
+	}{
+		{
+			name:      "create",
+			connector: Connector{Timeout: time.Minute, Timeouts: timeouts},
+			operation: "create",
+			want:      30 * time.Minute,
+		},
+		{
+			name:      "import",
+			connector: Connector{Timeout: time.Minute, Timeouts: timeouts},
+			operation: "import",
+			want:      5 * time.Minute,
+		},
+		{
+			name:      "no operation",
+			connector: Connector{Timeout: time.Minute, Timeouts: timeouts},
+			want:      time.Minute,
+		},
+		{
+			name:      "connect timeout",
+			connector: Connector{Timeout: time.Minute, Timeouts: timeouts, ConnectTimeout: 30 * time.Second},
+			operation: "delete",
+			want:      30 * time.Second,
+		},
+		{
+			name:      "connect timeout above operation timeout",
+			connector: Connector{Timeout: time.Minute, Timeouts: timeouts, ConnectTimeout: time.Hour},
+			operation: "update",
+			want:      20 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithOperation(context.Background(), Operation{Operation: tt.operation})
+			if got := tt.connector.connectTimeout(ctx); got != tt.want {
+				t.Fatalf("connectTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
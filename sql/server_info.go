@@ -0,0 +1,54 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"terraform-provider-sqlserver/sqlserver/model"
+)
+
+// serverInfoCache keeps the server info per server, as the edition and version
+// of a server do not change during a run.
+type serverInfoCache struct {
+	mu      sync.Mutex
+	entries map[string]*model.ServerInfo
+}
+
+func newServerInfoCache() *serverInfoCache {
+	return &serverInfoCache{entries: map[string]*model.ServerInfo{}}
+}
+
+// GetServerInfo returns the edition and version of the server.
+func (c *Connector) GetServerInfo(ctx context.Context) (*model.ServerInfo, error) {
+	cache := c.serverInfos
+	if cache != nil {
+		cache.mu.Lock()
+		info, ok := cache.entries[c.serverName()]
+		cache.mu.Unlock()
+		if ok {
+			return info, nil
+		}
+	}
+
+	var info model.ServerInfo
+	err := c.QueryRowContext(ctx,
+		`SELECT
+			CAST(SERVERPROPERTY('EngineEdition') AS int),
+			CAST(SERVERPROPERTY('Edition') AS nvarchar(128)),
+			CAST(SERVERPROPERTY('ProductVersion') AS nvarchar(128))`,
+		func(r *sql.Row) error {
+			return r.Scan(&info.EngineEdition, &info.Edition, &info.ProductVersion)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if cache != nil {
+		cache.mu.Lock()
+		cache.entries[c.serverName()] = &info
+		cache.mu.Unlock()
+	}
+	return &info, nil
+}
@@ -0,0 +1,35 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"terraform-provider-sqlserver/sqlserver/model"
+)
+
+func TestConnectorGetServerInfo(t *testing.T) {
+	fake := &fakeConnector{queryRow: []driver.Value{int64(4), "Express Edition (64-bit)", "15.0.2000.5"}}
+	c := &Connector{Host: "localhost", Port: "1433", pool: newConnectionPool(), serverInfos: newServerInfoCache()}
+	if _, err := c.pool.get(context.Background(), c.poolKey(), func(context.Context) (*sql.DB, error) { return sql.OpenDB(fake), nil }); err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+
+	want := &model.ServerInfo{EngineEdition: 4, Edition: "Express Edition (64-bit)", ProductVersion: "15.0.2000.5"}
+	for i := 0; i < 2; i++ {
+		got, err := c.GetServerInfo(context.Background())
+		if err != nil {
+			t.Fatalf("GetServerInfo() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("GetServerInfo() = %+v, want %+v", got, want)
+		}
+	}
+
+	// the info is queried once per server
+	if fake.queries != 1 {
+		t.Fatalf("GetServerInfo() queried %d times, want 1", fake.queries)
+	}
+}
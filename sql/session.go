@@ -0,0 +1,107 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Session identifies the sessions of the connector in sys.dm_exec_sessions.
+type Session struct {
+	// ApplicationName is the program_name of the sessions.
+	ApplicationName string
+	// WorkstationID is the host_name of the sessions. The driver defaults to the
+	// host name of the machine running Terraform.
+	WorkstationID string
+	// Context is set with sp_set_session_context on the session of every
+	// statement of ExecContext, together with the operation of the statement.
+	// Nil disables the session context.
+	Context map[string]string
+}
+
+// The session context keys of the operation executing a statement.
+const (
+	sessionResourceTypeKey = "terraform.resource_type"
+	sessionResourceIDKey   = "terraform.resource_id"
+	sessionOperationKey    = "terraform.operation"
+)
+
+// setSession adds the application name and workstation ID to the query.
+func (c *Connector) setSession(query url.Values) {
+	if c.Session.ApplicationName != "" {
+		query.Set("app name", c.Session.ApplicationName)
+	}
+	if c.Session.WorkstationID != "" {
+		query.Set("workstation id", c.Session.WorkstationID)
+	}
+}
+
+// exec executes the statement, on a session with the session context set if
+// it is enabled, executed as the ExecuteAs principal if set.
+func (c *Connector) exec(ctx context.Context, db *sql.DB, statement string, args []interface{}) error {
+	if c.Session.Context == nil && c.ExecuteAs == nil {
+		_, err := db.ExecContext(ctx, statement, args...)
+		return err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if c.Session.Context != nil {
+		batch, batchArgs := c.sessionContext(ctx)
+		if _, err := conn.ExecContext(ctx, batch, batchArgs...); err != nil {
+			return errors.Wrap(err, "unable to set the session context")
+		}
+	}
+	if c.ExecuteAs != nil {
+		revert, err := c.impersonate(ctx, conn)
+		if err != nil {
+			return err
+		}
+		defer revert()
+	}
+	_, err = conn.ExecContext(ctx, statement, args...)
+	return err
+}
+
+// sessionContext returns the batch setting the session context. The keys of
+// the operation are set on every statement, as pooled sessions keep the values
+// of the previous statement; empty values clear a key.
+func (c *Connector) sessionContext(ctx context.Context) (string, []interface{}) {
+	operation := operationFrom(ctx)
+	values := map[string]string{
+		sessionResourceTypeKey: operation.ResourceType,
+		sessionResourceIDKey:   operation.ResourceID,
+		sessionOperationKey:    operation.Operation,
+	}
+	for key, value := range c.Session.Context {
+		values[key] = value
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var batch strings.Builder
+	args := make([]interface{}, 0, 2*len(keys))
+	for _, key := range keys {
+		fmt.Fprintf(&batch, "EXEC sp_set_session_context @key = @p%d, @value = @p%d;\n", len(args)+1, len(args)+2)
+
+		var value interface{}
+		if values[key] != "" {
+			value = values[key]
+		}
+		args = append(args, key, value)
+	}
+	return batch.String(), args
+}
@@ -0,0 +1,79 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/microsoft/go-mssqldb/msdsn"
+)
+
+func TestConnectorSetSession(t *testing.T) {
+	c := &Connector{Host: "sql1", Port: "1433", Session: Session{ApplicationName: "terraform-provider-sqlserver", WorkstationID: "runner-1"}}
+	query := url.Values{}
+	c.setSession(query)
+
+	config, err := msdsn.Parse(c.connectionURL(query).String())
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if config.AppName != "terraform-provider-sqlserver" || config.Workstation != "runner-1" {
+		t.Fatalf("setSession() app name = %q, workstation = %q", config.AppName, config.Workstation)
+	}
+}
+
+func TestConnectorSessionContext(t *testing.T) {
+	c := &Connector{Session: Session{Context: map[string]string{"terraform.run_id": "run-1", "team": "data"}}}
+	ctx := WithOperation(context.Background(), Operation{ResourceType: "sqlserver_login", Operation: "create"})
+
+	batch, args := c.sessionContext(ctx)
+
+	if got := strings.Count(batch, "EXEC sp_set_session_context @key = @p"); got != 5 {
+		t.Fatalf("sessionContext() = %q, want 5 keys", batch)
+	}
+	want := []interface{}{
+		"team", "data",
+		"terraform.operation", "create",
+		"terraform.resource_id", nil,
+		"terraform.resource_type", "sqlserver_login",
+		"terraform.run_id", "run-1",
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Fatalf("sessionContext() args = %v, want %v", args, want)
+	}
+}
+
+func TestConnectorExecContextSessionContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		context map[string]string
+		want    int
+	}{
+		{name: "disabled", want: 1},
+		{name: "enabled", context: map[string]string{}, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeConnector{}
+			c := &Connector{Host: "localhost", Port: "1433", Session: Session{Context: tt.context}, pool: newConnectionPool()}
+			if _, err := c.pool.get(context.Background(), c.poolKey(), func(context.Context) (*sql.DB, error) { return sql.OpenDB(fake), nil }); err != nil {
+				t.Fatalf("get() error = %v", err)
+			}
+
+			if err := c.ExecContext(context.Background(), "DROP LOGIN [l]"); err != nil {
+				t.Fatalf("ExecContext() error = %v", err)
+			}
+
+			if len(fake.statements) != tt.want || fake.statements[len(fake.statements)-1] != "DROP LOGIN [l]" {
+				t.Fatalf("statements = %q, want %d ending with the statement", fake.statements, tt.want)
+			}
+			if tt.want > 1 && !strings.HasPrefix(fake.statements[0], "EXEC sp_set_session_context") {
+				t.Fatalf("statements = %q, want the session context first", fake.statements)
+			}
+		})
+	}
+}
@@ -5,33 +5,141 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
-	"log"
+	"net"
 	"net/url"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"terraform-provider-sqlserver/sqlserver/model"
 
 	"github.com/Azure/go-autorest/autorest/adal"
-	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	mssql "github.com/microsoft/go-mssqldb"
-	"github.com/microsoft/go-mssqldb/azuread"
+	"github.com/microsoft/go-mssqldb/msdsn"
 	"github.com/pkg/errors"
 )
 
-type factory struct{}
+// LogSubsystem is the tflog subsystem of the executed statements, enabled with
+// TF_LOG_PROVIDER_SQLSERVER_SQL.
+const LogSubsystem = "sql"
+
+type factory struct {
+	pool   *connectionPool
+	tokens *tokenCache
+	// files serializes the writes to the audit log and dry run script.
+	files       *appendLog
+	tunnels     *sshTunnels
+	locks       *serverLocks
+	serverInfos *serverInfoCache
+	credentials *credentialCache
+}
 
 func GetFactory() model.ConnectorFactory {
-	return new(factory)
+	return &factory{
+		pool:        newConnectionPool(),
+		tokens:      newTokenCache(),
+		files:       &appendLog{},
+		tunnels:     newSSHTunnels(),
+		locks:       newServerLocks(),
+		serverInfos: newServerInfoCache(),
+		credentials: newCredentialCache(),
+	}
 }
 
-func (f factory) GetConnector(data *schema.ResourceData, host string, port string, login interface{}) (interface{}, error) {
+func (f *factory) CredentialSecrets() []string {
+	return f.credentials.secrets()
+}
 
+func (f *factory) GetConnector(data *schema.ResourceData, host string, port string, instance string, login interface{}, options model.ConnectionOptions) (interface{}, error) {
+	connector := f.newConnector(host, port, instance, login, options)
+	connector.Timeout = data.Timeout(schema.TimeoutRead)
+	connector.Timeouts = Timeouts{
+		Create: data.Timeout(schema.TimeoutCreate),
+		Read:   data.Timeout(schema.TimeoutRead),
+		Update: data.Timeout(schema.TimeoutUpdate),
+		Delete: data.Timeout(schema.TimeoutDelete),
+	}
+	return connector, nil
+}
+
+// GetServerInfo returns the edition and version of the server, which are
+// queried once per server. Without a connect_timeout, the connect is attempted
+// once, as the server may not exist yet when planning.
+func (f *factory) GetServerInfo(ctx context.Context, host string, port string, instance string, login interface{}, options model.ConnectionOptions) (*model.ServerInfo, error) {
+	return f.newConnector(host, port, instance, login, options).GetServerInfo(ctx)
+}
+
+func (f *factory) newConnector(host string, port string, instance string, login interface{}, options model.ConnectionOptions) *Connector {
 	connector := &Connector{
-		Host:    host,
-		Port:    port,
-		Timeout: data.Timeout(schema.TimeoutRead),
+		Host:           host,
+		Port:           port,
+		Instance:       instance,
+		ConnectTimeout: options.ConnectTimeout,
+		TLS: TLS{
+			Mode:                   options.TLS.Mode,
+			CAFile:                 options.TLS.CAFile,
+			ServerName:             options.TLS.ServerName,
+			MinVersion:             options.TLS.MinVersion,
+			TrustServerCertificate: options.TLS.TrustServerCertificate,
+		},
+		Environment: Environment{
+			Name:          options.Environment.Name,
+			AuthorityHost: options.Environment.AuthorityHost,
+			TokenAudience: options.Environment.TokenAudience,
+		},
+		Retry: Retry{
+			MaxAttempts: options.Retry.MaxAttempts,
+			MaxBackoff:  options.Retry.MaxBackoff,
+		},
+		LogStatements: options.LogStatements,
+		AuditLogPath:  options.AuditLogPath,
+		DryRun: DryRun{
+			Enabled:    options.DryRun.Enabled,
+			ScriptPath: options.DryRun.ScriptPath,
+		},
+		ReadOnly: options.ReadOnly,
+		Session: Session{
+			ApplicationName: options.Session.ApplicationName,
+			WorkstationID:   options.Session.WorkstationID,
+			Context:         options.Session.Context,
+		},
+		pool:        f.pool,
+		poolOptions: options.Pool,
+		tokens:      f.tokens,
+		files:       f.files,
+		tunnels:     f.tunnels,
+		Parameters:  options.Parameters,
+		ApplicationLock: ApplicationLock{
+			Enabled:  options.ApplicationLock.Enabled,
+			Resource: options.ApplicationLock.Resource,
+			Timeout:  options.ApplicationLock.Timeout,
+		},
+		locks:            f.locks,
+		serverInfos:      f.serverInfos,
+		CredentialHelper: options.CredentialHelper,
+		credentials:      f.credentials,
+	}
+
+	if options.ExecuteAs != nil {
+		connector.ExecuteAs = &ExecuteAs{
+			Type: options.ExecuteAs.Type,
+			Name: options.ExecuteAs.Name,
+		}
+	}
+
+	if options.SSHTunnel != nil {
+		connector.SSHTunnel = &SSHTunnel{
+			Host:                 options.SSHTunnel.Host,
+			Port:                 options.SSHTunnel.Port,
+			User:                 options.SSHTunnel.User,
+			PrivateKey:           options.SSHTunnel.PrivateKey,
+			PrivateKeyPassphrase: options.SSHTunnel.PrivateKeyPassphrase,
+			Agent:                options.SSHTunnel.Agent,
+			KnownHostsFile:       options.SSHTunnel.KnownHostsFile,
+		}
 	}
 
 	if sqlLogin, ok := login.(model.SqlLogin); ok {
@@ -43,9 +151,12 @@ func (f factory) GetConnector(data *schema.ResourceData, host string, port strin
 
 	if azureLogin, ok := login.(model.AzureLogin); ok {
 		connector.AzureLogin = &AzureLogin{
-			TenantID:     azureLogin.TenantID,
-			ClientID:     azureLogin.ClientID,
-			ClientSecret: azureLogin.ClientSecret,
+			TenantID:                  azureLogin.TenantID,
+			ClientID:                  azureLogin.ClientID,
+			ClientSecret:              azureLogin.ClientSecret,
+			ClientCertificatePath:     azureLogin.ClientCertificatePath,
+			ClientCertificate:         azureLogin.ClientCertificate,
+			ClientCertificatePassword: azureLogin.ClientCertificatePassword,
 		}
 	}
 
@@ -55,18 +166,92 @@ func (f factory) GetConnector(data *schema.ResourceData, host string, port strin
 		}
 	}
 
-	return connector, nil
+	if workloadIdentity, ok := login.(model.FedauthWorkloadIdentity); ok {
+		connector.WorkloadIdentity = &WorkloadIdentity{
+			TenantID:      workloadIdentity.TenantID,
+			ClientID:      workloadIdentity.ClientID,
+			TokenFilePath: workloadIdentity.TokenFilePath,
+			AuthorityHost: workloadIdentity.AuthorityHost,
+		}
+	}
+
+	if accessToken, ok := login.(model.AccessToken); ok {
+		connector.AccessToken = &AccessToken{
+			Token:   accessToken.Token,
+			Command: accessToken.Command,
+		}
+	}
+
+	return connector
 }
 
+// Connector executes the statements of the resources on a server. A connector is
+// not changed once created, so it may be shared by concurrent operations; the
+// operations in a database use a copy from inDatabase.
 type Connector struct {
-	Host       string `json:"host"`
-	Port       string `json:"port"`
-	Database   string `json:"database"`
-	Login      *LoginUser
-	AzureLogin *AzureLogin
-	FedauthMSI *FedauthMSI
-	Timeout    time.Duration `json:"timeout,omitempty"`
-	Token      string
+	Host string `json:"host"`
+	Port string `json:"port"`
+	// Instance is the name of a named instance. Without a port, its port is
+	// resolved by the SQL Server Browser on UDP port 1434.
+	Instance         string `json:"instance,omitempty"`
+	Database         string `json:"database"`
+	Login            *LoginUser
+	AzureLogin       *AzureLogin
+	FedauthMSI       *FedauthMSI
+	WorkloadIdentity *WorkloadIdentity
+	AccessToken      *AccessToken
+	TLS              TLS
+	Environment      Environment
+	Retry            Retry
+	// Timeout bounds the connect of operations without a timeout in Timeouts.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// Timeouts are the timeouts of the operations of the resource, the connect
+	// of an operation is bounded by its timeout.
+	Timeouts Timeouts `json:"timeouts,omitempty"`
+	// ConnectTimeout bounds the connect further, if set.
+	ConnectTimeout time.Duration `json:"connect_timeout,omitempty"`
+	// LogStatements logs every statement to the LogSubsystem at debug level.
+	LogStatements bool
+	// AuditLogPath is the file the statements of ExecContext are appended to.
+	AuditLogPath string
+	// DryRun renders the statements of ExecContext instead of executing them.
+	DryRun DryRun
+	// ReadOnly refuses ExecContext and rolls back the transactions of queries.
+	ReadOnly bool
+	Session  Session
+	// SSHTunnel dials the server through a bastion host.
+	SSHTunnel *SSHTunnel
+	// Parameters are passed through to the driver, see ValidateConnectionParameters.
+	Parameters map[string]string
+	// ApplicationLock is taken by Lock.
+	ApplicationLock ApplicationLock
+	// ExecuteAs is the principal the statements of ExecContext are executed as.
+	ExecuteAs *ExecuteAs
+	// CredentialHelper is the command fetching the password or client secret of
+	// the login when connecting, if the login has none.
+	CredentialHelper []string
+
+	// pool is shared by all connectors of a provider. Connectors created without
+	// a pool open and close a database for every statement.
+	pool        *connectionPool
+	poolOptions model.ConnectionPool
+	// tokens is shared by all connectors of a provider. Connectors created
+	// without a cache request a new token for every connection.
+	tokens *tokenCache
+	// files serializes the writes to the audit log and dry run script.
+	files *appendLog
+	// tunnels is shared by all connectors of a provider. Connectors created
+	// without it open an SSH connection for every database.
+	tunnels *sshTunnels
+	// locks is shared by all connectors of a provider. Connectors created
+	// without it do not serialize their operations within the process.
+	locks *serverLocks
+	// serverInfos is shared by all connectors of a provider. Connectors created
+	// without it query the server info on every call.
+	serverInfos *serverInfoCache
+	// credentials is shared by all connectors of a provider. Connectors created
+	// without it run the credential helper for every connection.
+	credentials *credentialCache
 }
 
 type LoginUser struct {
@@ -75,20 +260,71 @@ type LoginUser struct {
 }
 
 type AzureLogin struct {
-	TenantID     string `json:"tenant_id,omitempty"`
-	ClientID     string `json:"client_id,omitempty"`
-	ClientSecret string `json:"client_secret,omitempty"`
+	TenantID                  string `json:"tenant_id,omitempty"`
+	ClientID                  string `json:"client_id,omitempty"`
+	ClientSecret              string `json:"client_secret,omitempty"`
+	ClientCertificatePath     string `json:"client_certificate_path,omitempty"`
+	ClientCertificate         string `json:"client_certificate,omitempty"`
+	ClientCertificatePassword string `json:"client_certificate_password,omitempty"`
 }
 
 type FedauthMSI struct {
 	UserID string `json:"user_id,omitempty"`
 }
 
+type WorkloadIdentity struct {
+	TenantID      string `json:"tenant_id,omitempty"`
+	ClientID      string `json:"client_id,omitempty"`
+	TokenFilePath string `json:"token_file_path,omitempty"`
+	AuthorityHost string `json:"authority_host,omitempty"`
+}
+
+type TLS struct {
+	Mode                   string `json:"mode,omitempty"`
+	CAFile                 string `json:"ca_file,omitempty"`
+	ServerName             string `json:"server_name,omitempty"`
+	MinVersion             string `json:"min_version,omitempty"`
+	TrustServerCertificate bool   `json:"trust_server_certificate,omitempty"`
+}
+
+// Timeouts are the timeouts of the operations of a resource.
+type Timeouts struct {
+	Create time.Duration `json:"create,omitempty"`
+	Read   time.Duration `json:"read,omitempty"`
+	Update time.Duration `json:"update,omitempty"`
+	Delete time.Duration `json:"delete,omitempty"`
+}
+
+// of returns the timeout of the operation, imports are bounded by the read
+// timeout. Unknown operations return 0.
+func (t Timeouts) of(operation string) time.Duration {
+	switch operation {
+	case "create":
+		return t.Create
+	case "read", "import":
+		return t.Read
+	case "update":
+		return t.Update
+	case "delete":
+		return t.Delete
+	}
+	return 0
+}
+
+// tlsEncryptModes maps the TLS modes of the provider to the encrypt parameter of the driver.
+var tlsEncryptModes = map[string]string{
+	"disable":  "disable",
+	"optional": "optional",
+	"required": "true",
+	"strict":   "strict",
+}
+
 func (c *Connector) PingContext(ctx context.Context) error {
-	db, err := c.db()
+	db, err := c.db(ctx)
 	if err != nil {
 		return err
 	}
+	defer c.release(db)
 
 	err = db.PingContext(ctx)
 	if err != nil {
@@ -100,65 +336,166 @@ func (c *Connector) PingContext(ctx context.Context) error {
 
 // Execute an SQL statement and ignore the results
 func (c *Connector) ExecContext(ctx context.Context, command string, args ...interface{}) error {
-	db, err := c.db()
+	if err := c.refuseWrite(command); err != nil {
+		return err
+	}
+	if c.DryRun.Enabled {
+		return c.renderScript(ctx, command, args)
+	}
+
+	db, err := c.db(ctx)
 	if err != nil {
 		return err
 	}
-	defer db.Close()
+	defer c.release(db)
 
-	_, err = db.ExecContext(ctx, command, args...)
+	audit, err := c.openAudit()
 	if err != nil {
 		return err
 	}
 
-	return nil
+	start := time.Now()
+	err = c.Retry.do(ctx, func() error {
+		return c.exec(ctx, db, command, args)
+	})
+	c.logStatement(ctx, command, args, start, err)
+	if auditErr := c.writeAudit(ctx, audit, command, args, start, err); auditErr != nil && err == nil {
+		return auditErr
+	}
+
+	return statementError(err, command, args)
 }
 
 func (c *Connector) QueryContext(ctx context.Context, query string, scanner func(*sql.Rows) error, args ...interface{}) error {
-	db, err := c.db()
+	db, err := c.db(ctx)
 	if err != nil {
 		return err
 	}
-	defer db.Close()
-
-	rows, err := db.QueryContext(ctx, query, args...)
+	defer c.release(db)
+
+	// only the query is retried, scanners may not be called twice
+	var rows *sql.Rows
+	var done func()
+	start := time.Now()
+	err = c.Retry.do(ctx, func() error {
+		q, rollback, err := c.queryer(ctx, db)
+		if err != nil {
+			return err
+		}
+		if rows, err = q.QueryContext(ctx, query, args...); err != nil {
+			rollback()
+			return err
+		}
+		done = rollback
+		return nil
+	})
+	c.logStatement(ctx, query, args, start, err)
 	if err != nil {
-		return err
+		return statementError(err, query, args)
 	}
+	defer done()
 	defer rows.Close()
 
 	err = scanner(rows)
 	if err != nil {
-		return err
+		return statementError(err, query, args)
 	}
 
 	return nil
 }
 
 func (c *Connector) QueryRowContext(ctx context.Context, query string, scanner func(*sql.Row) error, args ...interface{}) error {
-	db, err := c.db()
+	db, err := c.db(ctx)
 	if err != nil {
 		return err
 	}
-	defer db.Close()
+	defer c.release(db)
+
+	var row *sql.Row
+	var done func()
+	start := time.Now()
+	err = c.Retry.do(ctx, func() error {
+		q, rollback, err := c.queryer(ctx, db)
+		if err != nil {
+			return err
+		}
+		row = q.QueryRowContext(ctx, query, args...)
+		if err := row.Err(); err != nil {
+			rollback()
+			return err
+		}
+		done = rollback
+		return nil
+	})
+	c.logStatement(ctx, query, args, start, err)
+	if err != nil {
+		return statementError(err, query, args)
+	}
+	defer done()
 
-	row := db.QueryRowContext(ctx, query, args...)
-	if row.Err() != nil {
-		return row.Err()
+	return statementError(scanner(row), query, args)
+}
+
+// logStatement logs the statement with its secrets redacted. Secrets passed in
+// other places are masked by the subsystem of the resource.
+func (c *Connector) logStatement(ctx context.Context, statement string, args []interface{}, start time.Time, err error) {
+	if !c.LogStatements {
+		return
 	}
 
-	return scanner(row)
+	fields := map[string]interface{}{
+		"statement":   redactStatement(strings.TrimSpace(statement)),
+		"arguments":   redactArguments(args),
+		"duration_ms": time.Since(start).Milliseconds(),
+		"host":        c.Host,
+		"database":    c.Database,
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	tflog.SubsystemDebug(c.maskCredentials(ctx), LogSubsystem, "executed statement", fields)
 }
 
-func (c *Connector) db() (*sql.DB, error) {
+func (c *Connector) db(ctx context.Context) (*sql.DB, error) {
 	if c == nil {
 		panic("No connector")
 	}
-	conn, err := c.connector()
+	if c.pool != nil {
+		return c.pool.get(ctx, c.poolKey(), c.openPooled)
+	}
+	return c.open(ctx)
+}
+
+// release closes databases that were opened for a single statement; pooled
+// databases stay open for the lifetime of the provider.
+func (c *Connector) release(db *sql.DB) {
+	if c.pool == nil {
+		db.Close()
+	}
+}
+
+func (c *Connector) openPooled(ctx context.Context) (*sql.DB, error) {
+	db, err := c.open(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if db, err := connectLoop(conn, c.Timeout); err != nil {
+	db.SetMaxOpenConns(c.poolOptions.MaxOpenConnections)
+	db.SetMaxIdleConns(c.poolOptions.MaxIdleConnections)
+	db.SetConnMaxLifetime(c.poolOptions.ConnectionMaxLifetime)
+	return db, nil
+}
+
+func (c *Connector) open(ctx context.Context) (*sql.DB, error) {
+	connector, err := c.withCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = c.maskCredentials(ctx)
+	conn, err := connector.connector()
+	if err != nil {
+		return nil, err
+	}
+	if db, err := connectLoop(ctx, conn, c.connectTimeout(ctx), c.Retry); err != nil {
 		return nil, err
 	} else {
 		return db, nil
@@ -166,37 +503,128 @@ func (c *Connector) db() (*sql.DB, error) {
 }
 
 func (c *Connector) connector() (driver.Connector, error) {
+	connector, err := c.driverConnector()
+	if err != nil {
+		return nil, err
+	}
+	if c.SSHTunnel != nil {
+		if c.Instance != "" && c.Port == "" {
+			return nil, errors.Errorf("the port of instance %s is required with an SSH tunnel, as the SQL Server Browser cannot be reached through it", c.Instance)
+		}
+		tunnels := c.tunnels
+		if tunnels == nil {
+			tunnels = newSSHTunnels()
+		}
+		connector.Dialer = tunnels.get(*c.SSHTunnel)
+	}
+	return connector, nil
+}
+
+func (c *Connector) driverConnector() (*mssql.Connector, error) {
 	query := url.Values{}
-	host := fmt.Sprintf("%s:%s", c.Host, c.Port)
+	c.setParameters(query)
 	if c.Database != "" {
 		query.Set("database", c.Database)
 	}
-	if c.Login != nil || c.AzureLogin != nil {
-		connectionString := (&url.URL{
-			Scheme:   "sqlserver",
-			User:     c.userPassword(),
-			Host:     host,
-			RawQuery: query.Encode(),
-		}).String()
+	if err := c.setTLS(query); err != nil {
+		return nil, err
+	}
+	c.setSession(query)
+	if c.Login != nil || c.AzureLogin != nil || c.WorkloadIdentity != nil || c.AccessToken != nil {
+		connectionURL := c.connectionURL(query)
+		connectionURL.User = c.userPassword()
+		connectionString := connectionURL.String()
 		if c.Login != nil {
 			return mssql.NewConnector(connectionString)
 		}
-		return mssql.NewAccessTokenConnector(connectionString, func() (string, error) { return c.tokenProvider() })
+		return mssql.NewConnectorWithAccessTokenProvider(connectionString, c.tokenProvider)
 	}
+	config, err := msdsn.Parse(c.connectionURL(query).String())
+	if err != nil {
+		return nil, err
+	}
+	credential, err := c.credential()
+	if err != nil {
+		return nil, err
+	}
+	workflow := byte(mssql.FedAuthADALWorkflowPassword)
 	if c.FedauthMSI != nil {
-		query.Set("fedauth", "ActiveDirectoryManagedIdentity")
-		if c.FedauthMSI.UserID != "" {
-			query.Set("user id", c.FedauthMSI.UserID)
+		workflow = mssql.FedAuthADALWorkflowMSI
+	}
+	return mssql.NewActiveDirectoryTokenConnector(config, workflow, func(ctx context.Context, serverSPN, stsURL string) (string, error) {
+		// the server announces the audience it expects, unless it is overridden
+		audience := serverSPN
+		if c.Environment.TokenAudience != "" {
+			audience = c.Environment.TokenAudience
 		}
-	} else {
-		query.Set("fedauth", "ActiveDirectoryDefault")
+		key, err := c.credentialKey(audience)
+		if err != nil {
+			return "", err
+		}
+		return c.cachedToken(ctx, key, credentialTokenFetcher(credential, audienceScope(audience)))
+	})
+}
+
+// connectionURL returns the URL of the server. Named instances are passed as the
+// path, without a port the driver resolves their port with the SQL Server Browser.
+func (c *Connector) connectionURL(query url.Values) *url.URL {
+	host := c.Host
+	if c.Port != "" {
+		host = net.JoinHostPort(c.Host, c.Port)
 	}
-	connectionString := (&url.URL{
+
+	connectionURL := &url.URL{
 		Scheme:   "sqlserver",
 		Host:     host,
 		RawQuery: query.Encode(),
-	}).String()
-	return azuread.NewConnector(connectionString)
+	}
+	if c.Instance != "" {
+		connectionURL.Path = "/" + c.Instance
+	}
+	return connectionURL
+}
+
+// serverName returns the server of the connector as shown to users.
+func (c *Connector) serverName() string {
+	return ServerName(c.Host, c.Port, c.Instance)
+}
+
+// ServerName returns the server as shown to users: host:port, host\instance or
+// host\instance,port.
+func ServerName(host, port, instance string) string {
+	switch {
+	case instance == "":
+		return net.JoinHostPort(host, port)
+	case port == "":
+		return host + `\` + instance
+	default:
+		return host + `\` + instance + "," + port
+	}
+}
+
+// setTLS adds the encryption parameters to the query. Without a mode the driver
+// defaults apply, which encrypt the login only and trust any server certificate.
+func (c *Connector) setTLS(query url.Values) error {
+	if c.TLS.Mode != "" {
+		encrypt, ok := tlsEncryptModes[c.TLS.Mode]
+		if !ok {
+			return fmt.Errorf("invalid TLS mode %q", c.TLS.Mode)
+		}
+		query.Set("encrypt", encrypt)
+		query.Set("TrustServerCertificate", strconv.FormatBool(c.TLS.TrustServerCertificate))
+	} else if c.TLS.TrustServerCertificate {
+		query.Set("TrustServerCertificate", "true")
+	}
+	if c.TLS.CAFile != "" {
+		query.Set("certificate", c.TLS.CAFile)
+	}
+	if c.TLS.ServerName != "" {
+		query.Set("hostNameInCertificate", c.TLS.ServerName)
+	}
+	if c.TLS.MinVersion != "" {
+		query.Set("tlsmin", c.TLS.MinVersion)
+	}
+	return nil
 }
 
 func (c *Connector) userPassword() *url.Userinfo {
@@ -206,71 +634,190 @@ func (c *Connector) userPassword() *url.Userinfo {
 	return nil
 }
 
-func (c *Connector) tokenProvider() (string, error) {
-	const resourceID = "https://database.windows.net/"
+// tokenProvider returns the access token of the login. Fetching the token ends
+// with ctx, the context of the connect.
+func (c *Connector) tokenProvider(ctx context.Context) (string, error) {
+	if c.AccessToken != nil && c.AccessToken.Token != "" {
+		return c.AccessToken.Token, nil
+	}
 
-	admin := c.AzureLogin
-	oauthConfig, err := adal.NewOAuthConfig(azure.PublicCloud.ActiveDirectoryEndpoint, admin.TenantID)
+	key, fetch, err := c.tokenFetcher()
 	if err != nil {
 		return "", err
 	}
 
-	spt, err := adal.NewServicePrincipalToken(*oauthConfig, admin.ClientID, admin.ClientSecret, resourceID)
+	return c.cachedToken(ctx, key, fetch)
+}
+
+// tokenFetcher returns the cache key and the fetcher of the access token of the
+// login. The key identifies the tenant, client and audience of the token.
+func (c *Connector) tokenFetcher() (string, tokenFetcher, error) {
+	if c.AccessToken != nil {
+		command := c.AccessToken.Command
+		return tokenKey(append([]string{"command"}, command...)...), func(ctx context.Context) (string, time.Time, error) {
+			return runAccessTokenCommand(ctx, command)
+		}, nil
+	}
+
+	authorityHost, err := c.Environment.authorityHost()
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+	resource, err := c.Environment.tokenAudience()
+	if err != nil {
+		return "", nil, err
+	}
+
+	if c.WorkloadIdentity != nil {
+		w := c.WorkloadIdentity
+		key := tokenKey("workload", authorityHost, w.AuthorityHost, w.TenantID, w.ClientID, resource, w.TokenFilePath)
+		return key, servicePrincipalTokenFetcher(func() (*adal.ServicePrincipalToken, error) {
+			return w.servicePrincipalToken(authorityHost, resource)
+		}), nil
 	}
 
-	err = spt.EnsureFresh()
+	l := c.AzureLogin
+	key := tokenKey("azure", authorityHost, l.TenantID, l.ClientID, resource,
+		l.ClientSecret, l.ClientCertificatePath, l.ClientCertificate, l.ClientCertificatePassword)
+	return key, servicePrincipalTokenFetcher(func() (*adal.ServicePrincipalToken, error) {
+		return l.servicePrincipalToken(authorityHost, resource)
+	}), nil
+}
+
+// credentialKey returns the cache key of the tokens of the managed identity or
+// default credential chain.
+func (c *Connector) credentialKey(audience string) (string, error) {
+	authorityHost, err := c.Environment.authorityHost()
 	if err != nil {
 		return "", err
 	}
+	if c.FedauthMSI != nil {
+		return tokenKey("msi", authorityHost, c.FedauthMSI.UserID, audience), nil
+	}
+	return tokenKey("default", authorityHost, audience), nil
+}
 
-	c.Token = spt.OAuthToken()
+func (c *Connector) cachedToken(ctx context.Context, key string, fetch tokenFetcher) (string, error) {
+	if c.tokens == nil {
+		token, _, err := fetch(ctx)
+		return token, err
+	}
+	return c.tokens.token(ctx, key, fetch)
+}
 
-	return spt.OAuthToken(), nil
+func servicePrincipalTokenFetcher(newToken func() (*adal.ServicePrincipalToken, error)) tokenFetcher {
+	return func(ctx context.Context) (string, time.Time, error) {
+		spt, err := newToken()
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		if err = spt.EnsureFreshWithContext(ctx); err != nil {
+			return "", time.Time{}, err
+		}
+		token := spt.Token()
+		return token.AccessToken, token.Expires(), nil
+	}
 }
 
-func connectLoop(connector driver.Connector, timeout time.Duration) (*sql.DB, error) {
-	ticker := time.NewTicker(250 * time.Millisecond)
-	defer ticker.Stop()
+// servicePrincipalToken authenticates with the client certificate if one is
+// configured and with the client secret otherwise.
+func (l *AzureLogin) servicePrincipalToken(activeDirectoryEndpoint, resource string) (*adal.ServicePrincipalToken, error) {
+	oauthConfig, err := adal.NewOAuthConfig(activeDirectoryEndpoint, l.TenantID)
+	if err != nil {
+		return nil, err
+	}
 
-	timeoutExceeded := time.After(timeout)
-	for {
-		select {
-		case <-timeoutExceeded:
-			return nil, fmt.Errorf("db connection failed after %s timeout", timeout)
+	if l.hasClientCertificate() {
+		certificate, key, err := l.clientCertificate()
+		if err != nil {
+			return nil, err
+		}
+		return adal.NewServicePrincipalTokenFromCertificate(*oauthConfig, l.ClientID, certificate, key, resource)
+	}
 
-		case <-ticker.C:
-			db, err := connect(connector)
-			if err == nil {
-				return db, nil
-			}
-			if strings.Contains(strings.ToLower(err.Error()), "login failed") {
-				return nil, err
-			}
-			if strings.Contains(strings.ToLower(err.Error()), "login error") {
-				return nil, err
-			}
-			if strings.Contains(err.Error(), "error retrieving access token") {
-				return nil, err
-			}
-			if strings.Contains(err.Error(), "AuthenticationFailedError") {
-				return nil, err
-			}
-			if strings.Contains(err.Error(), "credential") {
-				return nil, err
-			}
-			if strings.Contains(err.Error(), "request failed") {
-				return nil, err
+	return adal.NewServicePrincipalToken(*oauthConfig, l.ClientID, l.ClientSecret, resource)
+}
+
+// servicePrincipalToken exchanges the federated token in TokenFilePath for an
+// access token. The file is read again on every refresh, as it is rotated by
+// the platform. The AuthorityHost of the identity takes precedence over the one
+// of the environment.
+func (w *WorkloadIdentity) servicePrincipalToken(authorityHost, resource string) (*adal.ServicePrincipalToken, error) {
+	if w.AuthorityHost != "" {
+		authorityHost = w.AuthorityHost
+	}
+
+	oauthConfig, err := adal.NewOAuthConfig(authorityHost, w.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return adal.NewServicePrincipalTokenFromFederatedTokenCallback(*oauthConfig, w.ClientID, func() (string, error) {
+		jwt, err := os.ReadFile(w.TokenFilePath)
+		if err != nil {
+			return "", errors.Wrap(err, "unable to read federated token file")
+		}
+		return strings.TrimSpace(string(jwt)), nil
+	}, resource)
+}
+
+// connectTimeout returns the timeout of the operation of ctx, bounded by the
+// ConnectTimeout of the connector.
+func (c *Connector) connectTimeout(ctx context.Context) time.Duration {
+	timeout := c.Timeout
+	if t := c.Timeouts.of(operationFrom(ctx).Operation); t > 0 {
+		timeout = t
+	}
+	if c.ConnectTimeout > 0 && (timeout <= 0 || c.ConnectTimeout < timeout) {
+		timeout = c.ConnectTimeout
+	}
+	return timeout
+}
+
+// connectLoop retries transient connection errors with backoff until the
+// timeout is exceeded or ctx ends. Other errors, like failed logins, are
+// returned at once. Without a timeout, the connect is attempted once.
+func connectLoop(ctx context.Context, connector driver.Connector, timeout time.Duration, retry Retry) (*sql.DB, error) {
+	parent := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	for attempt := 1; ; attempt++ {
+		db, err := connect(ctx, connector)
+		if err == nil {
+			return db, nil
+		}
+		if parent.Err() != nil {
+			return nil, errors.Wrapf(err, "db connection cancelled: %s", parent.Err())
+		}
+		if ctx.Err() == nil && !isTransient(err) {
+			return nil, err
+		}
+		if ctx.Err() != nil || timeout <= 0 {
+			return nil, errors.Wrapf(err, "db connection failed after %s timeout", timeout)
+		}
+		tflog.Warn(ctx, "failed to connect to database, retrying", map[string]interface{}{
+			"attempt": attempt,
+			"error":   err.Error(),
+		})
+
+		select {
+		case <-ctx.Done():
+			if parent.Err() != nil {
+				return nil, errors.Wrapf(err, "db connection cancelled: %s", parent.Err())
 			}
-			log.Println(errors.Wrap(err, "failed to connect to database"))
+			return nil, errors.Wrapf(err, "db connection failed after %s timeout", timeout)
+		case <-time.After(retry.backoff(attempt)):
 		}
 	}
 }
 
-func connect(connector driver.Connector) (*sql.DB, error) {
+func connect(ctx context.Context, connector driver.Connector) (*sql.DB, error) {
 	db := sql.OpenDB(connector)
-	if err := db.Ping(); err != nil {
+	if err := db.PingContext(ctx); err != nil {
 		db.Close()
 		return nil, err
 	}
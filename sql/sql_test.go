@@ -0,0 +1,194 @@
+package sql
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/microsoft/go-mssqldb/msdsn"
+)
+
+// testTokenServer mocks the OAuth token endpoint of tenant "tenant". validate
+// returns a message if the token request is rejected.
+func testTokenServer(t *testing.T, token string, validate func(form url.Values) string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tenant/oauth2/token" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if msg := validate(r.PostForm); msg != "" {
+			http.Error(w, msg, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"access_token": token,
+			"token_type":   "Bearer",
+			"expires_in":   "3600",
+			"expires_on":   strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10),
+			"resource":     r.PostForm.Get("resource"),
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestConnectorSetTLS(t *testing.T) {
+	tests := []struct {
+		name    string
+		tls     TLS
+		want    url.Values
+		wantErr bool
+	}{
+		{
+			name: "driver defaults",
+			tls:  TLS{},
+			want: url.Values{},
+		},
+		{
+			name: "strict with CA bundle",
+			tls:  TLS{Mode: "strict", CAFile: "/etc/ssl/ca.pem", ServerName: "sql.example.com", MinVersion: "1.2"},
+			want: url.Values{
+				"encrypt":                {"strict"},
+				"TrustServerCertificate": {"false"},
+				"certificate":            {"/etc/ssl/ca.pem"},
+				"hostNameInCertificate":  {"sql.example.com"},
+				"tlsmin":                 {"1.2"},
+			},
+		},
+		{
+			name: "required with self-signed certificate",
+			tls:  TLS{Mode: "required", TrustServerCertificate: true},
+			want: url.Values{
+				"encrypt":                {"true"},
+				"TrustServerCertificate": {"true"},
+			},
+		},
+		{
+			name: "disabled",
+			tls:  TLS{Mode: "disable"},
+			want: url.Values{
+				"encrypt":                {"disable"},
+				"TrustServerCertificate": {"false"},
+			},
+		},
+		{
+			name:    "invalid mode",
+			tls:     TLS{Mode: "always"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Connector{TLS: tt.tls}
+			query := url.Values{}
+			err := c.setTLS(query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setTLS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if query.Encode() != tt.want.Encode() {
+				t.Fatalf("setTLS() = %s, want %s", query.Encode(), tt.want.Encode())
+			}
+		})
+	}
+}
+
+func TestConnectorConnectionURL(t *testing.T) {
+	tests := []struct {
+		name           string
+		connector      Connector
+		wantPort       uint64
+		wantInstance   string
+		wantServerName string
+	}{
+		{
+			name:           "default instance",
+			connector:      Connector{Host: "sql1", Port: "1433"},
+			wantPort:       1433,
+			wantServerName: "sql1:1433",
+		},
+		{
+			name:           "named instance resolved by the browser",
+			connector:      Connector{Host: "sql1", Instance: "SQLEXPRESS"},
+			wantInstance:   "SQLEXPRESS",
+			wantServerName: `sql1\SQLEXPRESS`,
+		},
+		{
+			name:           "named instance with port",
+			connector:      Connector{Host: "sql1", Port: "14330", Instance: "SQLEXPRESS"},
+			wantPort:       14330,
+			wantInstance:   "SQLEXPRESS",
+			wantServerName: `sql1\SQLEXPRESS,14330`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := msdsn.Parse(tt.connector.connectionURL(url.Values{}).String())
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if config.Host != tt.connector.Host || config.Port != tt.wantPort || config.Instance != tt.wantInstance {
+				t.Fatalf("connectionURL() = %s:%d/%s, want %s:%d/%s", config.Host, config.Port, config.Instance, tt.connector.Host, tt.wantPort, tt.wantInstance)
+			}
+			if got := tt.connector.serverName(); got != tt.wantServerName {
+				t.Fatalf("serverName() = %s, want %s", got, tt.wantServerName)
+			}
+		})
+	}
+}
+
+func TestWorkloadIdentityServicePrincipalToken(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("federated-jwt\n"), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	server := testTokenServer(t, "workload-token", func(form url.Values) string {
+		if form.Get("client_assertion_type") != "urn:ietf:params:oauth:client-assertion-type:jwt-bearer" {
+			return "expected a client assertion"
+		}
+		if form.Get("client_assertion") != "federated-jwt" {
+			return "unexpected federated token " + form.Get("client_assertion")
+		}
+		if form.Get("client_id") != "client" {
+			return "unexpected client"
+		}
+		return ""
+	})
+
+	identity := &WorkloadIdentity{
+		TenantID:      "tenant",
+		ClientID:      "client",
+		TokenFilePath: tokenFile,
+		AuthorityHost: server.URL,
+	}
+
+	spt, err := identity.servicePrincipalToken("https://login.microsoftonline.com/", "https://database.windows.net/")
+	if err != nil {
+		t.Fatalf("servicePrincipalToken() error = %v", err)
+	}
+	if err = spt.EnsureFresh(); err != nil {
+		t.Fatalf("EnsureFresh() error = %v", err)
+	}
+	if token := spt.OAuthToken(); token != "workload-token" {
+		t.Fatalf("OAuthToken() = %q, want %q", token, "workload-token")
+	}
+}
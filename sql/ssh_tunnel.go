@@ -0,0 +1,205 @@
+package sql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SSHTunnel dials the connections to the server through an SSH bastion host.
+type SSHTunnel struct {
+	Host                 string
+	Port                 string
+	User                 string
+	PrivateKey           string
+	PrivateKeyPassphrase string
+	// Agent authenticates with the keys of the agent listening on SSH_AUTH_SOCK.
+	Agent bool
+	// KnownHostsFile verifies the host key of the bastion host, ~/.ssh/known_hosts
+	// by default.
+	KnownHostsFile string
+}
+
+// sshTunnels shares the SSH connections of the connectors of a provider.
+type sshTunnels struct {
+	mu      sync.Mutex
+	tunnels map[string]*sshTunnel
+}
+
+func newSSHTunnels() *sshTunnels {
+	return &sshTunnels{tunnels: map[string]*sshTunnel{}}
+}
+
+// get returns the tunnel of the configuration, which connects on its first dial.
+func (t *sshTunnels) get(config SSHTunnel) *sshTunnel {
+	hash := sha256.Sum256([]byte(strings.Join([]string{config.Host, config.Port, config.User,
+		config.PrivateKey, config.PrivateKeyPassphrase, strconv.FormatBool(config.Agent), config.KnownHostsFile}, "\x00")))
+	key := hex.EncodeToString(hash[:])
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if tunnel, ok := t.tunnels[key]; ok {
+		return tunnel
+	}
+	tunnel := &sshTunnel{config: config}
+	t.tunnels[key] = tunnel
+	return tunnel
+}
+
+// sshTunnel is a dialer of the driver opening the TCP connections to the server
+// on the bastion host. Host names are resolved by the bastion host.
+type sshTunnel struct {
+	config SSHTunnel
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+// HostName marks the tunnel as a dialer resolving host names in the network it
+// dials to.
+func (t *sshTunnel) HostName() string {
+	return t.config.Host
+}
+
+func (t *sshTunnel) DialContext(ctx context.Context, network string, addr string) (net.Conn, error) {
+	// the SQL Server Browser is queried over UDP, which cannot be tunneled
+	if network != "tcp" {
+		return nil, errors.Errorf("ssh tunnel: unable to dial %s %s", network, addr)
+	}
+
+	client, err := t.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "ssh tunnel: unable to dial %s through %s", addr, t.bastion())
+	}
+	return conn, nil
+}
+
+// connect returns the SSH connection to the bastion host, opening it if it is
+// not open. Connections closed by the bastion host are reopened.
+func (t *sshTunnel) connect(ctx context.Context) (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client != nil {
+		return t.client, nil
+	}
+
+	config, closeAuth, err := t.clientConfig()
+	if err != nil {
+		return nil, err
+	}
+	defer closeAuth()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", t.bastion())
+	if err != nil {
+		return nil, errors.Wrapf(err, "ssh tunnel: unable to connect to %s", t.bastion())
+	}
+	sshConn, channels, requests, err := ssh.NewClientConn(conn, t.bastion(), config)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrapf(err, "ssh tunnel: unable to connect to %s", t.bastion())
+	}
+
+	client := ssh.NewClient(sshConn, channels, requests)
+	t.client = client
+	go func() {
+		_ = client.Wait()
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.client == client {
+			t.client = nil
+		}
+	}()
+	return client, nil
+}
+
+// clientConfig returns the configuration of the SSH connection. closeAuth closes
+// the connection to the agent once the connection is authenticated.
+func (t *sshTunnel) clientConfig() (config *ssh.ClientConfig, closeAuth func(), err error) {
+	knownHostsFile, err := expandHome(t.config.KnownHostsFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "ssh tunnel: unable to read the known hosts")
+	}
+
+	closeAuth = func() {}
+	var auth []ssh.AuthMethod
+	if t.config.PrivateKey != "" {
+		var signer ssh.Signer
+		if t.config.PrivateKeyPassphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(t.config.PrivateKey), []byte(t.config.PrivateKeyPassphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(t.config.PrivateKey))
+		}
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "ssh tunnel: unable to parse the private key")
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if t.config.Agent {
+		socket := os.Getenv("SSH_AUTH_SOCK")
+		if socket == "" {
+			return nil, nil, errors.New("ssh tunnel: SSH_AUTH_SOCK is not set")
+		}
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "ssh tunnel: unable to connect to the agent")
+		}
+		closeAuth = func() { conn.Close() }
+		auth = append(auth, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+	}
+	if len(auth) == 0 {
+		return nil, nil, errors.New("ssh tunnel: neither private key nor agent specified")
+	}
+
+	return &ssh.ClientConfig{
+		User:            t.config.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+	}, closeAuth, nil
+}
+
+func (t *sshTunnel) bastion() string {
+	port := t.config.Port
+	if port == "" {
+		port = "22"
+	}
+	return net.JoinHostPort(t.config.Host, port)
+}
+
+// expandHome replaces a leading ~ of the path by the home directory, defaulting
+// to ~/.ssh/known_hosts.
+func expandHome(path string) (string, error) {
+	if path == "" {
+		path = "~/.ssh/known_hosts"
+	}
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
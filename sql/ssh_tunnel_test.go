@@ -0,0 +1,264 @@
+package sql
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testSSHServer is a bastion host forwarding direct-tcpip channels, like sshd.
+type testSSHServer struct {
+	addr      string
+	hostKey   ssh.Signer
+	clientKey ed25519.PrivateKey
+
+	mu          sync.Mutex
+	connections int
+}
+
+func newTestSSHServer(t *testing.T) *testSSHServer {
+	t.Helper()
+
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatalf("NewSignerFromKey() error = %v", err)
+	}
+	clientPublicKey, clientKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	authorized, err := ssh.NewPublicKey(clientPublicKey)
+	if err != nil {
+		t.Fatalf("NewPublicKey() error = %v", err)
+	}
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == "tunnel" && bytes.Equal(key.Marshal(), authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, io.EOF
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &testSSHServer{addr: listener.Addr().String(), hostKey: hostSigner, clientKey: clientKey}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn, config)
+		}
+	}()
+	return server
+}
+
+func (s *testSSHServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.connections++
+	s.mu.Unlock()
+
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		var target struct {
+			Host       string
+			Port       uint32
+			OriginHost string
+			OriginPort uint32
+		}
+		if err := ssh.Unmarshal(newChannel.ExtraData(), &target); err != nil {
+			_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		targetConn, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+		if err != nil {
+			_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			targetConn.Close()
+			continue
+		}
+		go ssh.DiscardRequests(channelRequests)
+		go func() {
+			defer channel.Close()
+			defer targetConn.Close()
+			go func() { _, _ = io.Copy(targetConn, channel) }()
+			_, _ = io.Copy(channel, targetConn)
+		}()
+	}
+}
+
+// tunnel returns the configuration of a tunnel to the server, trusting the host
+// key of the server if trusted is set.
+func (s *testSSHServer) tunnel(t *testing.T, trusted bool) SSHTunnel {
+	t.Helper()
+
+	host, port, _ := net.SplitHostPort(s.addr)
+	hostKey := s.hostKey.PublicKey()
+	if !trusted {
+		_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+		otherSigner, _ := ssh.NewSignerFromKey(otherKey)
+		hostKey = otherSigner.PublicKey()
+	}
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(s.addr)}, hostKey)
+	if err := os.WriteFile(knownHostsFile, []byte(line+"\n"), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	block, err := ssh.MarshalPrivateKey(s.clientKey, "")
+	if err != nil {
+		t.Fatalf("MarshalPrivateKey() error = %v", err)
+	}
+	return SSHTunnel{
+		Host:           host,
+		Port:           port,
+		User:           "tunnel",
+		PrivateKey:     string(pem.EncodeToMemory(block)),
+		KnownHostsFile: knownHostsFile,
+	}
+}
+
+// newEchoServer returns the address of a TCP server echoing every line.
+func newEchoServer(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestSSHTunnelDialContext(t *testing.T) {
+	server := newTestSSHServer(t)
+	echo := newEchoServer(t)
+	tunnels := newSSHTunnels()
+	config := server.tunnel(t, true)
+
+	for i := 0; i < 2; i++ {
+		// every connector of the provider gets the tunnel of the configuration
+		conn, err := tunnels.get(config).DialContext(context.Background(), "tcp", echo)
+		if err != nil {
+			t.Fatalf("DialContext() error = %v", err)
+		}
+		if _, err := conn.Write([]byte("ping\n")); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		got := make([]byte, len("ping\n"))
+		if _, err := io.ReadFull(conn, got); err != nil || string(got) != "ping\n" {
+			t.Fatalf("Read() = %q, %v, want ping", got, err)
+		}
+		conn.Close()
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.connections != 1 {
+		t.Fatalf("SSH connections = %d, want 1", server.connections)
+	}
+}
+
+func TestSSHTunnelErrors(t *testing.T) {
+	server := newTestSSHServer(t)
+	echo := newEchoServer(t)
+
+	tests := []struct {
+		name    string
+		network string
+		tunnel  func() SSHTunnel
+		wantErr string
+	}{
+		{
+			name:    "unknown host key",
+			network: "tcp",
+			tunnel:  func() SSHTunnel { return server.tunnel(t, false) },
+			wantErr: "key mismatch",
+		},
+		{
+			name:    "no key",
+			network: "tcp",
+			tunnel: func() SSHTunnel {
+				tunnel := server.tunnel(t, true)
+				tunnel.PrivateKey = ""
+				return tunnel
+			},
+			wantErr: "neither private key nor agent specified",
+		},
+		{
+			name:    "sql server browser",
+			network: "udp",
+			tunnel:  func() SSHTunnel { return server.tunnel(t, true) },
+			wantErr: "unable to dial udp",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newSSHTunnels().get(tt.tunnel()).DialContext(context.Background(), tt.network, echo)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("DialContext() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConnectorSSHTunnelInstance(t *testing.T) {
+	c := &Connector{Host: "sql1", Instance: "SQLEXPRESS", SSHTunnel: &SSHTunnel{Host: "bastion"}}
+	if _, err := c.connector(); err == nil || !strings.Contains(err.Error(), "port of instance SQLEXPRESS is required") {
+		t.Fatalf("connector() error = %v, want the port to be required", err)
+	}
+
+	c.Port = "14330"
+	if _, err := c.connector(); err != nil {
+		t.Fatalf("connector() error = %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package sql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// tokenExpiryMargin is how long before expiry a token is no longer handed out
+	tokenExpiryMargin = 2 * time.Minute
+	// tokenRefreshWindow is how long before expiry a token is refreshed in the background
+	tokenRefreshWindow = 10 * time.Minute
+	// tokenRefreshTimeout bounds a background refresh
+	tokenRefreshTimeout = time.Minute
+)
+
+// tokenFetcher obtains a new access token and its expiry. A zero expiry means
+// the token must not be reused.
+type tokenFetcher func(ctx context.Context) (string, time.Time, error)
+
+// tokenCache shares access tokens between all connectors of a provider, so a
+// token is requested once per tenant, client and audience instead of once per
+// connection.
+type tokenCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedToken
+}
+
+type cachedToken struct {
+	mu         sync.Mutex
+	token      string
+	expiresOn  time.Time
+	refreshing bool
+}
+
+func newTokenCache() *tokenCache {
+	return &tokenCache{entries: make(map[string]*cachedToken)}
+}
+
+// token returns the cached token of key, calling fetch if there is none or it
+// is about to expire. Tokens close to expiry are refreshed in the background
+// while the current token is still handed out.
+func (c *tokenCache) token(ctx context.Context, key string, fetch tokenFetcher) (string, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &cachedToken{}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	now := time.Now()
+	if entry.token != "" && now.Add(tokenExpiryMargin).Before(entry.expiresOn) {
+		if !entry.refreshing && !now.Add(tokenRefreshWindow).Before(entry.expiresOn) {
+			entry.refreshing = true
+			go entry.refresh(fetch)
+		}
+		return entry.token, nil
+	}
+
+	token, expiresOn, err := fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	entry.token, entry.expiresOn = token, expiresOn
+
+	return token, nil
+}
+
+// refresh replaces the token. Errors are ignored, the token is fetched again
+// when it is requested after it expired.
+func (t *cachedToken) refresh(fetch tokenFetcher) {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenRefreshTimeout)
+	defer cancel()
+
+	token, expiresOn, err := fetch(ctx)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.refreshing = false
+	if err == nil {
+		t.token, t.expiresOn = token, expiresOn
+	}
+}
+
+// tokenKey hashes the parts identifying a token, so secrets are not kept around
+// as part of the key.
+func tokenKey(parts ...string) string {
+	hash := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(hash[:])
+}
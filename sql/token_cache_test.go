@@ -0,0 +1,147 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTokenCache(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresIn time.Duration
+		want      []string
+	}{
+		{
+			name:      "valid token is reused",
+			expiresIn: time.Hour,
+			want:      []string{"token-1", "token-1", "token-1"},
+		},
+		{
+			name:      "token close to expiry is fetched again",
+			expiresIn: time.Minute,
+			want:      []string{"token-1", "token-2", "token-3"},
+		},
+		{
+			name:      "token without expiry is fetched again",
+			expiresIn: 0,
+			want:      []string{"token-1", "token-2", "token-3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			fetch := func(ctx context.Context) (string, time.Time, error) {
+				calls++
+				var expiresOn time.Time
+				if tt.expiresIn != 0 {
+					expiresOn = time.Now().Add(tt.expiresIn)
+				}
+				return fmt.Sprintf("token-%d", calls), expiresOn, nil
+			}
+
+			cache := newTokenCache()
+			for i, want := range tt.want {
+				token, err := cache.token(context.Background(), "key", fetch)
+				if err != nil {
+					t.Fatalf("token() error = %v", err)
+				}
+				if token != want {
+					t.Fatalf("token() call %d = %q, want %q", i+1, token, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenCacheBackgroundRefresh(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	refreshed := make(chan struct{})
+	fetch := func(ctx context.Context) (string, time.Time, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			// within the refresh window, but still valid
+			return "token-1", time.Now().Add(5 * time.Minute), nil
+		}
+		close(refreshed)
+		return "token-2", time.Now().Add(time.Hour), nil
+	}
+
+	cache := newTokenCache()
+	for _, want := range []string{"token-1", "token-1"} {
+		token, err := cache.token(context.Background(), "key", fetch)
+		if err != nil {
+			t.Fatalf("token() error = %v", err)
+		}
+		if token != want {
+			t.Fatalf("token() = %q, want %q", token, want)
+		}
+	}
+
+	select {
+	case <-refreshed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("token was not refreshed in the background")
+	}
+
+	// the refresh stores the token after the fetch returned
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		token, err := cache.token(context.Background(), "key", fetch)
+		if err != nil {
+			t.Fatalf("token() error = %v", err)
+		}
+		if token == "token-2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("token() = %q after refresh, want %q", token, "token-2")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 2 {
+		t.Fatalf("fetch called %d times, want 2", calls)
+	}
+}
+
+func TestTokenCacheSharedByConnectors(t *testing.T) {
+	tokens := newTokenCache()
+	first := &Connector{AccessToken: &AccessToken{Command: []string{"helper"}}, tokens: tokens}
+	second := &Connector{AccessToken: &AccessToken{Command: []string{"helper"}}, tokens: tokens}
+	other := &Connector{AccessToken: &AccessToken{Command: []string{"other-helper"}}, tokens: tokens}
+
+	firstKey, _, _ := first.tokenFetcher()
+	secondKey, _, _ := second.tokenFetcher()
+	otherKey, _, _ := other.tokenFetcher()
+	if firstKey != secondKey {
+		t.Fatalf("tokenFetcher() keys differ for the same command")
+	}
+	if firstKey == otherKey {
+		t.Fatalf("tokenFetcher() keys equal for different commands")
+	}
+
+	azure := &Connector{AzureLogin: &AzureLogin{TenantID: "tenant", ClientID: "client", ClientSecret: "secret"}}
+	gov := &Connector{AzureLogin: &AzureLogin{TenantID: "tenant", ClientID: "client", ClientSecret: "secret"}, Environment: Environment{Name: "usgovernment"}}
+	azureKey, _, err := azure.tokenFetcher()
+	if err != nil {
+		t.Fatalf("tokenFetcher() error = %v", err)
+	}
+	govKey, _, err := gov.tokenFetcher()
+	if err != nil {
+		t.Fatalf("tokenFetcher() error = %v", err)
+	}
+	if azureKey == govKey {
+		t.Fatalf("tokenFetcher() keys equal for different audiences")
+	}
+}
@@ -28,15 +28,14 @@ func (c *Connector) GetUser(ctx context.Context, database, username string) (*mo
 		sid   []byte
 		roles string
 	)
-	err := c.
-		setDatabase(&database).
-		QueryRowContext(ctx, cmd,
-			func(r *sql.Row) error {
-				return r.Scan(&user.PrincipalID, &user.Username, &user.AuthType, &sid, &user.SIDStr, &user.LoginName, &roles)
-			},
-			sql.Named("database", database),
-			sql.Named("username", username),
-		)
+	db := c.inDatabase(database)
+	err := db.QueryRowContext(ctx, cmd,
+		func(r *sql.Row) error {
+			return r.Scan(&user.PrincipalID, &user.Username, &user.AuthType, &sid, &user.SIDStr, &user.LoginName, &roles)
+		},
+		sql.Named("database", db.Database),
+		sql.Named("username", username),
+	)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -45,8 +44,7 @@ func (c *Connector) GetUser(ctx context.Context, database, username string) (*mo
 	}
 	if user.AuthType == "INSTANCE" && user.LoginName == "" {
 		cmd = "SELECT name FROM [sys].[sql_logins] WHERE sid = @sid"
-		c.Database = "master"
-		err = c.QueryRowContext(ctx, cmd,
+		err = c.inDatabase("master").QueryRowContext(ctx, cmd,
 			func(r *sql.Row) error {
 				return r.Scan(&user.LoginName)
 			},
@@ -101,16 +99,15 @@ func (c *Connector) CreateUser(ctx context.Context, database string, user *model
 			return err
 		}
 	}
-	return c.
-		setDatabase(&database).
-		ExecContext(ctx, cmd,
-			sql.Named("database", database),
-			sql.Named("username", user.Username),
-			sql.Named("loginName", user.LoginName),
-			sql.Named("password", user.Password),
-			sql.Named("authType", user.AuthType),
-			sql.Named("roles", strings.Join(user.Roles, ",")),
-		)
+	db := c.inDatabase(database)
+	return db.ExecContext(ctx, cmd,
+		sql.Named("database", db.Database),
+		sql.Named("username", user.Username),
+		sql.Named("loginName", user.LoginName),
+		sql.Named("password", user.Password),
+		sql.Named("authType", user.AuthType),
+		sql.Named("roles", strings.Join(user.Roles, ",")),
+	)
 }
 
 func (c *Connector) UpdateUser(ctx context.Context, database string, user *model.User) error {
@@ -171,13 +168,12 @@ func (c *Connector) UpdateUser(ctx context.Context, database string, user *model
 	EXEC (@stmt)
 	`
 
-	return c.
-		setDatabase(&database).
-		ExecContext(ctx, cmd,
-			sql.Named("database", database),
-			sql.Named("username", user.Username),
-			sql.Named("roles", strings.Join(user.Roles, ",")),
-		)
+	db := c.inDatabase(database)
+	return db.ExecContext(ctx, cmd,
+		sql.Named("database", db.Database),
+		sql.Named("username", user.Username),
+		sql.Named("roles", strings.Join(user.Roles, ",")),
+	)
 }
 
 func (c *Connector) DeleteUser(ctx context.Context, database, username string) error {
@@ -185,15 +181,18 @@ func (c *Connector) DeleteUser(ctx context.Context, database, username string) e
           SET @stmt = 'IF EXISTS (SELECT 1 FROM ' + QuoteName(@database) + '.[sys].[database_principals] WHERE [name] = ' + QuoteName(@username, '''') + ') ' +
                       'DROP USER ' + QuoteName(@username)
           EXEC (@stmt)`
-	return c.
-		setDatabase(&database).
-		ExecContext(ctx, cmd, sql.Named("database", database), sql.Named("username", username))
+	db := c.inDatabase(database)
+	return db.ExecContext(ctx, cmd, sql.Named("database", db.Database), sql.Named("username", username))
 }
 
-func (c *Connector) setDatabase(database *string) *Connector {
-	if *database == "" {
-		*database = "master"
+// inDatabase returns a copy of the connector for the database, master if
+// empty. The connector itself is not changed, as it is shared by the concurrent
+// operations of a resource.
+func (c *Connector) inDatabase(database string) *Connector {
+	if database == "" {
+		database = "master"
 	}
-	c.Database = *database
-	return c
+	connector := *c
+	connector.Database = database
+	return &connector
 }
@@ -0,0 +1,100 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"testing"
+
+	"terraform-provider-sqlserver/sqlserver/model"
+)
+
+func TestConnectorInDatabase(t *testing.T) {
+	tests := []struct {
+		name     string
+		database string
+		want     string
+	}{
+		{
+			name:     "database",
+			database: "app",
+			want:     "app",
+		},
+		{
+			name: "default",
+			want: "master",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Connector{Host: "localhost", Port: "1433"}
+			if got := c.inDatabase(tt.database).Database; got != tt.want {
+				t.Fatalf("inDatabase() = %q, want %q", got, tt.want)
+			}
+			if c.Database != "" {
+				t.Fatalf("inDatabase() changed the connector to %q", c.Database)
+			}
+		})
+	}
+}
+
+// TestConnectorUsersConcurrently runs the user operations of several databases
+// on a single connector, as the parallel walks of Terraform do. Run with -race.
+func TestConnectorUsersConcurrently(t *testing.T) {
+	const (
+		databases  = 4
+		operations = 25
+	)
+
+	c := &Connector{Host: "localhost", Port: "1433", pool: newConnectionPool()}
+	fakes := map[string]*fakeConnector{}
+	for i := 0; i < databases; i++ {
+		database := fmt.Sprintf("db%d", i)
+		fake := &fakeConnector{queryRow: []driver.Value{int64(5), "u", "DATABASE", []byte{1}, "0x01", "", "db_datareader"}}
+		fakes[database] = fake
+		// the databases are only reachable with the pool key of their connector
+		key := c.inDatabase(database).poolKey()
+		if _, err := c.pool.get(context.Background(), key, func(context.Context) (*sql.DB, error) { return sql.OpenDB(fake), nil }); err != nil {
+			t.Fatalf("get() error = %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*databases*operations)
+	for database := range fakes {
+		for i := 0; i < operations; i++ {
+			wg.Add(2)
+			go func(database string) {
+				defer wg.Done()
+				user, err := c.GetUser(context.Background(), database, "u")
+				if err == nil && user.Username != "u" {
+					err = fmt.Errorf("GetUser() = %+v in %s", user, database)
+				}
+				errs <- err
+			}(database)
+			go func(database string) {
+				defer wg.Done()
+				errs <- c.CreateUser(context.Background(), database, &model.User{Username: "u", AuthType: "EXTERNAL"})
+			}(database)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent operation error = %v", err)
+		}
+	}
+	for database, fake := range fakes {
+		if fake.queries != operations || fake.execs != operations {
+			t.Fatalf("%s: %d queries and %d statements, want %d of each", database, fake.queries, fake.execs, operations)
+		}
+	}
+	if c.Database != "" {
+		t.Fatalf("Database = %q, want the connector unchanged", c.Database)
+	}
+}
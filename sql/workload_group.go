@@ -48,6 +48,8 @@ func (c *Connector) GetWorkloadGroup(ctx context.Context, name string) (*model.W
 }
 
 func (c *Connector) CreateWorkloadGroup(ctx context.Context, group *model.WorkloadGroup) error {
+	defer c.lockResourceGovernor()()
+
 	cmd := fmt.Sprintf(`CREATE WORKLOAD GROUP %s WITH (
 		IMPORTANCE = %s,
 		REQUEST_MAX_MEMORY_GRANT_PERCENT = %d,
@@ -75,6 +77,8 @@ func (c *Connector) CreateWorkloadGroup(ctx context.Context, group *model.Worklo
 }
 
 func (c *Connector) UpdateWorkloadGroup(ctx context.Context, group *model.WorkloadGroup) error {
+	defer c.lockResourceGovernor()()
+
 	return c.withSessionDrainRetry(
 		ctx,
 		func(ctx context.Context) error {
@@ -110,6 +114,8 @@ func (c *Connector) UpdateWorkloadGroup(ctx context.Context, group *model.Worklo
 }
 
 func (c *Connector) DeleteWorkloadGroup(ctx context.Context, name string) error {
+	defer c.lockResourceGovernor()()
+
 	return c.withSessionDrainRetry(
 		ctx,
 		func(ctx context.Context) error {
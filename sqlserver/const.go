@@ -1,6 +1,8 @@
 package sqlserver
 
 const (
+	serverProp             = "server"
+	executeAsProp          = "execute_as"
 	databaseProp           = "database"
 	principalIdProp        = "principal_id"
 	usernameProp           = "username"
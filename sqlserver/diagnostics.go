@@ -0,0 +1,137 @@
+package sqlserver
+
+import (
+	"fmt"
+	"strings"
+
+	"terraform-provider-sqlserver/sql"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/pkg/errors"
+)
+
+// errorAttributes maps SQL Server error numbers to the attribute holding the
+// value the server rejected.
+type errorAttributes map[int32]cty.Path
+
+// sqlDiagnostics converts err into diagnostics. Every message of a SQL Server
+// error becomes a diagnostic with its number, severity, state, procedure and
+// line, and the last one shows the failing statement. Other errors are wrapped
+// with summary.
+func sqlDiagnostics(err error, summary string, attributes errorAttributes) diag.Diagnostics {
+	var sqlErr mssql.Error
+	if !errors.As(err, &sqlErr) {
+		return diag.FromErr(errors.Wrap(err, summary))
+	}
+
+	var statementErr *sql.StatementError
+	errors.As(err, &statementErr)
+
+	messages := sqlErr.All
+	if len(messages) == 0 {
+		messages = []mssql.Error{sqlErr}
+	}
+
+	diags := make(diag.Diagnostics, 0, len(messages))
+	for i, message := range messages {
+		detail := errorDetail(message)
+		if i == len(messages)-1 && statementErr != nil {
+			detail += "\n\n" + statementDetail(statementErr)
+		}
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Error,
+			Summary:       fmt.Sprintf("%s: %s", summary, message.Message),
+			Detail:        detail,
+			AttributePath: attributes[message.Number],
+		})
+	}
+
+	return diags
+}
+
+func errorDetail(e mssql.Error) string {
+	detail := fmt.Sprintf("SQL Server error %d, severity %d, state %d", e.Number, e.Class, e.State)
+	if e.ProcName != "" {
+		detail += fmt.Sprintf(", procedure %s", e.ProcName)
+	}
+	if e.LineNo > 0 {
+		detail += fmt.Sprintf(", line %d", e.LineNo)
+	}
+	if e.ServerName != "" {
+		detail += fmt.Sprintf(", server %s", e.ServerName)
+	}
+	return detail
+}
+
+func statementDetail(e *sql.StatementError) string {
+	detail := "Statement:\n" + strings.TrimSpace(e.Statement)
+	if len(e.Arguments) > 0 {
+		detail += "\n\nArguments:\n" + strings.Join(e.Arguments, "\n")
+	}
+	return detail
+}
+
+// blockAttribute returns the path of an attribute in a block with MaxItems 1.
+func blockAttribute(block, attribute string) cty.Path {
+	return cty.GetAttrPath(block).IndexInt(0).GetAttr(attribute)
+}
+
+func loginErrorAttributes(data *schema.ResourceData) errorAttributes {
+	if _, ok := data.GetOk(LoginSourceTypeExternal); ok {
+		loginName := blockAttribute(LoginSourceTypeExternal, loginNameProp)
+		return errorAttributes{
+			15025: loginName, // principal already exists
+			15151: loginName, // cannot find the login
+			33130: loginName, // principal not found in Azure AD
+		}
+	}
+
+	loginName := blockAttribute(LoginSourceTypeSQL, loginNameProp)
+	password := blockAttribute(LoginSourceTypeSQL, passwordProp)
+	return errorAttributes{
+		15025: loginName, // principal already exists
+		15151: loginName, // cannot find the login
+		15115: password,  // password too long
+		15116: password,  // password too short
+		15118: password,  // password not complex enough
+	}
+}
+
+func userErrorAttributes(data *schema.ResourceData) errorAttributes {
+	attributes := errorAttributes{
+		911:  cty.GetAttrPath(databaseProp), // database does not exist
+		4060: cty.GetAttrPath(databaseProp), // cannot open database
+	}
+
+	for _, sourceType := range UserSourceTypes {
+		if _, ok := data.GetOk(sourceType); !ok {
+			continue
+		}
+		attributes[15023] = blockAttribute(sourceType, usernameProp) // user already exists
+		switch sourceType {
+		case UserSourceTypeInstance:
+			attributes[15007] = blockAttribute(sourceType, loginNameProp) // not a valid login
+			attributes[15063] = blockAttribute(sourceType, loginNameProp) // login already has a user
+		case UserSourceTypeDatabase:
+			attributes[15115] = blockAttribute(sourceType, passwordProp)
+			attributes[15116] = blockAttribute(sourceType, passwordProp)
+			attributes[15118] = blockAttribute(sourceType, passwordProp)
+		case UserSourceTypeExternal:
+			attributes[33130] = blockAttribute(sourceType, usernameProp) // principal not found in Azure AD
+		}
+	}
+
+	return attributes
+}
+
+var classifierFunctionErrorAttributes = errorAttributes{
+	102:  cty.GetAttrPath(functionBodyProp),           // incorrect syntax
+	156:  cty.GetAttrPath(functionBodyProp),           // incorrect syntax near keyword
+	207:  cty.GetAttrPath(functionBodyProp),           // invalid column name
+	208:  cty.GetAttrPath(functionBodyProp),           // invalid object name
+	2714: cty.GetAttrPath(classifierFunctionNameProp), // object already exists
+	2760: cty.GetAttrPath(schemaNameProp),             // schema does not exist
+}
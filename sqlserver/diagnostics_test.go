@@ -0,0 +1,108 @@
+package sqlserver
+
+import (
+	"strings"
+	"testing"
+
+	"terraform-provider-sqlserver/sql"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/pkg/errors"
+)
+
+func TestSqlDiagnostics(t *testing.T) {
+	loginName := blockAttribute(LoginSourceTypeSQL, loginNameProp)
+	exists := mssql.Error{Number: 15025, Class: 16, State: 2, Message: "The server principal 'l' already exists.", ServerName: "sql1", LineNo: 1}
+	batch := mssql.Error{Number: 3621, Class: 0, State: 1, Message: "The statement has been terminated.", ProcName: "sp_create"}
+	all := batch
+	all.All = []mssql.Error{exists, batch}
+
+	tests := []struct {
+		name string
+		err  error
+		want []diag.Diagnostic
+	}{
+		{
+			name: "connection error",
+			err:  errors.New("connection refused"),
+			want: []diag.Diagnostic{{Severity: diag.Error, Summary: "unable to create login [l]: connection refused"}},
+		},
+		{
+			name: "single server error",
+			err:  exists,
+			want: []diag.Diagnostic{{
+				Severity:      diag.Error,
+				Summary:       "unable to create login [l]: The server principal 'l' already exists.",
+				Detail:        "SQL Server error 15025, severity 16, state 2, line 1, server sql1",
+				AttributePath: loginName,
+			}},
+		},
+		{
+			name: "all messages with statement",
+			err: &sql.StatementError{
+				Statement: "\n  EXEC (@sql)\n",
+				Arguments: []string{"@name = 'l'", "@password = <redacted>"},
+				Err:       all,
+			},
+			want: []diag.Diagnostic{
+				{
+					Severity:      diag.Error,
+					Summary:       "unable to create login [l]: The server principal 'l' already exists.",
+					Detail:        "SQL Server error 15025, severity 16, state 2, line 1, server sql1",
+					AttributePath: loginName,
+				},
+				{
+					Severity: diag.Error,
+					Summary:  "unable to create login [l]: The statement has been terminated.",
+					Detail:   "SQL Server error 3621, severity 0, state 1, procedure sp_create\n\nStatement:\nEXEC (@sql)\n\nArguments:\n@name = 'l'\n@password = <redacted>",
+				},
+			},
+		},
+	}
+
+	attributes := errorAttributes{15025: loginName}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := sqlDiagnostics(tt.err, "unable to create login [l]", attributes)
+			if len(diags) != len(tt.want) {
+				t.Fatalf("sqlDiagnostics() returned %d diagnostics, want %d: %v", len(diags), len(tt.want), diags)
+			}
+			for i, want := range tt.want {
+				got := diags[i]
+				if got.Severity != want.Severity || got.Summary != want.Summary || got.Detail != want.Detail {
+					t.Fatalf("sqlDiagnostics()[%d] = %#v, want %#v", i, got, want)
+				}
+				if !got.AttributePath.Equals(want.AttributePath) {
+					t.Fatalf("sqlDiagnostics()[%d] path = %#v, want %#v", i, got.AttributePath, want.AttributePath)
+				}
+				if strings.Contains(got.Detail, "secret") {
+					t.Fatalf("sqlDiagnostics()[%d] detail contains a secret", i)
+				}
+			}
+		})
+	}
+}
+
+func TestUserErrorAttributes(t *testing.T) {
+	data := resourceUser().TestResourceData()
+	if err := data.Set(UserSourceTypeInstance, []interface{}{map[string]interface{}{usernameProp: "u", loginNameProp: "l"}}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	attributes := userErrorAttributes(data)
+	want := map[int32]cty.Path{
+		911:   cty.GetAttrPath(databaseProp),
+		15023: blockAttribute(UserSourceTypeInstance, usernameProp),
+		15007: blockAttribute(UserSourceTypeInstance, loginNameProp),
+	}
+	for number, path := range want {
+		if !attributes[number].Equals(path) {
+			t.Fatalf("userErrorAttributes()[%d] = %#v, want %#v", number, attributes[number], path)
+		}
+	}
+	if _, ok := attributes[15118]; ok {
+		t.Fatalf("userErrorAttributes() maps password errors of an instance user")
+	}
+}
@@ -0,0 +1,58 @@
+package sqlserver
+
+import (
+	"context"
+	"fmt"
+
+	"terraform-provider-sqlserver/sql"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+type resourceFunc = func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics
+
+// wrapWriteFunctions wraps the create, update and delete functions of the
+// resources.
+func wrapWriteFunctions(resources map[string]*schema.Resource, wrap func(resourceType, operation string, f resourceFunc) resourceFunc) {
+	for resourceType, resource := range resources {
+		if resource.CreateContext != nil {
+			resource.CreateContext = wrap(resourceType, "create", resource.CreateContext)
+		}
+		if resource.UpdateContext != nil {
+			resource.UpdateContext = wrap(resourceType, "update", resource.UpdateContext)
+		}
+		if resource.DeleteContext != nil {
+			resource.DeleteContext = wrap(resourceType, "delete", resource.DeleteContext)
+		}
+	}
+}
+
+// dryRun fails write operations of a provider in dry run mode with the script
+// of the statements that were rendered instead of executed. The state is left
+// unchanged, so the next plan shows the same changes.
+func dryRun(resourceType, operation string, f resourceFunc) resourceFunc {
+	return func(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		if !meta.(sqlserverProvider).options.DryRun.Enabled {
+			return f(ctx, data, meta)
+		}
+
+		ctx, script := sql.WithScript(ctx)
+		diags := f(ctx, data, meta)
+		if script.Empty() {
+			return diags
+		}
+
+		switch operation {
+		case "create":
+			data.SetId("")
+		case "update":
+			data.Partial(true)
+		}
+		return append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("dry run: the statements to %s %s were not executed", operation, resourceType),
+			Detail:   script.String(),
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package sqlserver
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"terraform-provider-sqlserver/sql"
+	"terraform-provider-sqlserver/sqlserver/model"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDryRun(t *testing.T) {
+	createLogin := func(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		connector := &sql.Connector{Host: "sql1.invalid", Port: "1433", DryRun: sql.DryRun{Enabled: meta.(sqlserverProvider).options.DryRun.Enabled}}
+		data.SetId("sqlserver://sql1.invalid:1433/login/l")
+		return diag.FromErr(connector.CreateLogin(ctx, "l", "login-password", "SQL"))
+	}
+
+	tests := []struct {
+		name        string
+		dryRun      bool
+		operation   string
+		wantSummary string
+		wantID      string
+	}{
+		{
+			name:        "create",
+			dryRun:      true,
+			operation:   "create",
+			wantSummary: "dry run: the statements to create sqlserver_login were not executed",
+		},
+		{
+			name:        "update",
+			dryRun:      true,
+			operation:   "update",
+			wantSummary: "dry run: the statements to update sqlserver_login were not executed",
+			wantID:      "sqlserver://sql1.invalid:1433/login/l",
+		},
+		{
+			name:        "disabled",
+			operation:   "create",
+			wantSummary: "db connection failed",
+			wantID:      "sqlserver://sql1.invalid:1433/login/l",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := sqlserverProvider{options: model.ConnectionOptions{DryRun: model.DryRun{Enabled: tt.dryRun}}}
+			data := schema.TestResourceDataRaw(t, resourceLogin().Schema, map[string]interface{}{})
+
+			diags := dryRun("sqlserver_login", tt.operation, createLogin)(context.Background(), data, meta)
+
+			if len(diags) != 1 || !strings.HasPrefix(diags[0].Summary, tt.wantSummary) {
+				t.Fatalf("dryRun() = %v, want %q", diags, tt.wantSummary)
+			}
+			if tt.dryRun && !strings.Contains(diags[0].Detail, "DECLARE @password nvarchar(max) = N'<redacted>'") {
+				t.Fatalf("dryRun() detail = %q, want the script", diags[0].Detail)
+			}
+			if got := data.Id(); got != tt.wantID {
+				t.Fatalf("Id() = %q, want %q", got, tt.wantID)
+			}
+		})
+	}
+}
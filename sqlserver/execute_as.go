@@ -0,0 +1,55 @@
+package sqlserver
+
+import (
+	"terraform-provider-sqlserver/sqlserver/model"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// getExecuteAsSchema returns the schema of the execute_as block of the provider
+// and the resources. The block of a resource overrides the one of the provider.
+func getExecuteAsSchema(description string) *schema.Schema {
+	principals := []string{executeAsProp + ".0.login", executeAsProp + ".0.user"}
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		MaxItems:    1,
+		Optional:    true,
+		Description: description,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"login": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					ExactlyOneOf: principals,
+					ValidateFunc: validation.StringIsNotWhiteSpace,
+					Description:  "The login to execute the statements as, with EXECUTE AS LOGIN.",
+				},
+				"user": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					ExactlyOneOf: principals,
+					ValidateFunc: validation.StringIsNotWhiteSpace,
+					Description:  "The user to execute the statements as, with EXECUTE AS USER in the database of the resource.",
+				},
+			},
+		},
+	}
+}
+
+// executeAsFromData reads the execute_as block. It returns nil if the block is
+// not set.
+func executeAsFromData(data serverData) *model.ExecuteAs {
+	v, ok := data.GetOk(executeAsProp)
+	if !ok {
+		return nil
+	}
+	executeAs, ok := v.([]interface{})[0].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if login := executeAs["login"].(string); login != "" {
+		return &model.ExecuteAs{Type: "LOGIN", Name: login}
+	}
+	return &model.ExecuteAs{Type: "USER", Name: executeAs["user"].(string)}
+}
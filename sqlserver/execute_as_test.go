@@ -0,0 +1,42 @@
+package sqlserver
+
+import (
+	"reflect"
+	"testing"
+
+	"terraform-provider-sqlserver/sqlserver/model"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestExecuteAsFromData(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		want   *model.ExecuteAs
+	}{
+		{
+			name:   "not set",
+			config: map[string]interface{}{},
+		},
+		{
+			name:   "login",
+			config: map[string]interface{}{"execute_as": []interface{}{map[string]interface{}{"login": "deployer"}}},
+			want:   &model.ExecuteAs{Type: "LOGIN", Name: "deployer"},
+		},
+		{
+			name:   "user",
+			config: map[string]interface{}{"execute_as": []interface{}{map[string]interface{}{"user": "deployer"}}},
+			want:   &model.ExecuteAs{Type: "USER", Name: "deployer"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := schema.TestResourceDataRaw(t, resourceUser().Schema, tt.config)
+			if got := executeAsFromData(data); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("executeAsFromData() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package sqlserver
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"terraform-provider-sqlserver/sqlserver/model"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pkg/errors"
+)
+
+// Engine editions of SERVERPROPERTY('EngineEdition').
+const (
+	engineEditionPersonal        = 1
+	engineEditionStandard        = 2
+	engineEditionEnterprise      = 3
+	engineEditionExpress         = 4
+	engineEditionAzureSQL        = 5
+	engineEditionManagedInstance = 8
+)
+
+// feature is a feature of SQL Server used by resources, which is not supported
+// by every edition and version.
+type feature struct {
+	name string
+	// requirement names the editions and versions supporting the feature.
+	requirement string
+	supported   func(info model.ServerInfo) bool
+}
+
+var resourceGovernorFeature = feature{
+	name:        "Resource Governor",
+	requirement: "SQL Server Enterprise or Developer edition, SQL Server 2025 (17.x) Standard edition or Azure SQL Managed Instance",
+	supported: func(info model.ServerInfo) bool {
+		switch info.EngineEdition {
+		case engineEditionPersonal, engineEditionExpress, engineEditionAzureSQL:
+			return false
+		case engineEditionStandard:
+			return majorVersion(info) >= 17
+		}
+		return true
+	},
+}
+
+var externalLoginFeature = feature{
+	name:        "CREATE LOGIN FROM EXTERNAL PROVIDER",
+	requirement: "SQL Server 2022 (16.x), Azure SQL Database or Azure SQL Managed Instance",
+	supported: func(info model.ServerInfo) bool {
+		switch info.EngineEdition {
+		case engineEditionPersonal, engineEditionStandard, engineEditionEnterprise, engineEditionExpress:
+			return majorVersion(info) >= 16
+		}
+		return true
+	},
+}
+
+// check returns an error naming the requirement of the feature if the server
+// does not support it. Editions unknown to the provider are assumed to support
+// every feature.
+func (f feature) check(info model.ServerInfo) error {
+	if f.supported(info) {
+		return nil
+	}
+	return errors.Errorf("%s is not supported by %s %s, it requires %s", f.name, info.Edition, info.ProductVersion, f.requirement)
+}
+
+// majorVersion returns the major version of the product version, e.g. 16 for
+// SQL Server 2022.
+func majorVersion(info model.ServerInfo) int {
+	major, _, _ := strings.Cut(info.ProductVersion, ".")
+	version, _ := strconv.Atoi(major)
+	return version
+}
+
+// requireFeature returns a CustomizeDiff failing the plan of a new resource on a
+// server that does not support the feature. uses limits the check to the
+// configurations using the feature, nil checks every configuration.
+func requireFeature(f feature, uses func(diff *schema.ResourceDiff) bool) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+		if diff.Id() != "" && !diff.HasChange(serverProp) {
+			return nil
+		}
+		if uses != nil && !uses(diff) {
+			return nil
+		}
+		provider, ok := meta.(model.Provider)
+		if !ok {
+			return nil
+		}
+
+		info, err := provider.GetServerInfo(ctx, diff)
+		if err != nil {
+			// the server may not exist yet, the check is repeated when applying
+			tflog.Warn(ctx, fmt.Sprintf("unable to detect whether the server supports %s", f.name), map[string]interface{}{"error": err.Error()})
+			return nil
+		}
+		if info == nil {
+			return nil
+		}
+		return f.check(*info)
+	}
+}
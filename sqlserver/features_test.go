@@ -0,0 +1,207 @@
+package sqlserver
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"terraform-provider-sqlserver/sqlserver/model"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/pkg/errors"
+)
+
+func TestFeatureCheck(t *testing.T) {
+	express2019 := model.ServerInfo{EngineEdition: 4, Edition: "Express Edition (64-bit)", ProductVersion: "15.0.2000.5"}
+	enterprise2019 := model.ServerInfo{EngineEdition: 3, Edition: "Enterprise Edition (64-bit)", ProductVersion: "15.0.4153.1"}
+	standard2022 := model.ServerInfo{EngineEdition: 2, Edition: "Standard Edition (64-bit)", ProductVersion: "16.0.1000.6"}
+	standard2025 := model.ServerInfo{EngineEdition: 2, Edition: "Standard Edition (64-bit)", ProductVersion: "17.0.1000.7"}
+	azureSQL := model.ServerInfo{EngineEdition: 5, Edition: "SQL Azure", ProductVersion: "12.0.2000.8"}
+	managedInstance := model.ServerInfo{EngineEdition: 8, Edition: "SQL Azure", ProductVersion: "12.0.2000.8"}
+
+	tests := []struct {
+		name    string
+		feature feature
+		info    model.ServerInfo
+		wantErr string
+	}{
+		{
+			name:    "resource governor on express",
+			feature: resourceGovernorFeature,
+			info:    express2019,
+			wantErr: "Resource Governor is not supported by Express Edition (64-bit) 15.0.2000.5, it requires SQL Server Enterprise or Developer edition",
+		},
+		{
+			name:    "resource governor on azure sql database",
+			feature: resourceGovernorFeature,
+			info:    azureSQL,
+			wantErr: "Resource Governor is not supported by SQL Azure",
+		},
+		{
+			name:    "resource governor on standard 2022",
+			feature: resourceGovernorFeature,
+			info:    standard2022,
+			wantErr: "Resource Governor is not supported",
+		},
+		{
+			name:    "resource governor on standard 2025",
+			feature: resourceGovernorFeature,
+			info:    standard2025,
+		},
+		{
+			name:    "resource governor on enterprise",
+			feature: resourceGovernorFeature,
+			info:    enterprise2019,
+		},
+		{
+			name:    "resource governor on managed instance",
+			feature: resourceGovernorFeature,
+			info:    managedInstance,
+		},
+		{
+			name:    "external login on sql server 2019",
+			feature: externalLoginFeature,
+			info:    enterprise2019,
+			wantErr: "it requires SQL Server 2022 (16.x)",
+		},
+		{
+			name:    "external login on sql server 2022",
+			feature: externalLoginFeature,
+			info:    standard2022,
+		},
+		{
+			name:    "external login on azure sql database",
+			feature: externalLoginFeature,
+			info:    azureSQL,
+		},
+		{
+			name:    "unknown edition",
+			feature: resourceGovernorFeature,
+			info:    model.ServerInfo{EngineEdition: 12, Edition: "Unknown", ProductVersion: "12.0.2000.8"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.feature.check(tt.info)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("check() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("check() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// serverInfoFactory returns info or err from GetServerInfo.
+type serverInfoFactory struct {
+	model.ConnectorFactory
+	info  *model.ServerInfo
+	err   error
+	hosts map[string]bool
+}
+
+func (f *serverInfoFactory) GetServerInfo(_ context.Context, host string, _ string, _ string, _ interface{}, _ model.ConnectionOptions) (*model.ServerInfo, error) {
+	f.hosts[host] = true
+	return f.info, f.err
+}
+
+func TestRequireFeature(t *testing.T) {
+	express := &model.ServerInfo{EngineEdition: 4, Edition: "Express Edition (64-bit)", ProductVersion: "16.0.1000.6"}
+
+	tests := []struct {
+		name     string
+		resource *schema.Resource
+		state    *terraform.InstanceState
+		config   map[string]interface{}
+		info     *model.ServerInfo
+		err      error
+		wantHost string
+		wantErr  string
+	}{
+		{
+			name:     "unsupported",
+			resource: resourceResourcePool(),
+			config:   map[string]interface{}{"name": "pool"},
+			info:     express,
+			wantHost: "sql1.example.com",
+			wantErr:  "Resource Governor is not supported by Express Edition (64-bit) 16.0.1000.6",
+		},
+		{
+			name:     "server of the resource",
+			resource: resourceResourcePool(),
+			config:   map[string]interface{}{"name": "pool", "server": []interface{}{map[string]interface{}{"host": "sql2.example.com"}}},
+			info:     express,
+			wantHost: "sql2.example.com",
+			wantErr:  "Resource Governor is not supported",
+		},
+		{
+			name:     "supported",
+			resource: resourceResourcePool(),
+			config:   map[string]interface{}{"name": "pool"},
+			info:     &model.ServerInfo{EngineEdition: 3, Edition: "Developer Edition (64-bit)", ProductVersion: "16.0.1000.6"},
+			wantHost: "sql1.example.com",
+		},
+		{
+			name:     "server unreachable",
+			resource: resourceResourcePool(),
+			config:   map[string]interface{}{"name": "pool"},
+			err:      errors.New("db connection failed"),
+			wantHost: "sql1.example.com",
+		},
+		{
+			name:     "existing resource",
+			resource: resourceResourcePool(),
+			state:    &terraform.InstanceState{ID: "sqlserver://sql1.example.com:1433/resource_pool/pool", Attributes: map[string]string{"name": "pool"}},
+			config:   map[string]interface{}{"name": "pool", "max_cpu_percent": 50},
+			info:     express,
+		},
+		{
+			name:     "classifier function",
+			resource: resourceClassifierFunction(),
+			config:   map[string]interface{}{"name": "classifier", "function_body": "RETURN N'default'"},
+			info:     express,
+			wantHost: "sql1.example.com",
+			wantErr:  "Resource Governor is not supported by Express Edition (64-bit) 16.0.1000.6",
+		},
+		{
+			name:     "external login",
+			resource: resourceLogin(),
+			config:   map[string]interface{}{"external_login": []interface{}{map[string]interface{}{"login_name": "l"}}},
+			info:     &model.ServerInfo{EngineEdition: 2, Edition: "Standard Edition (64-bit)", ProductVersion: "15.0.4153.1"},
+			wantHost: "sql1.example.com",
+			wantErr:  "CREATE LOGIN FROM EXTERNAL PROVIDER is not supported by Standard Edition (64-bit) 15.0.4153.1",
+		},
+		{
+			name:     "sql login",
+			resource: resourceLogin(),
+			config:   map[string]interface{}{"sql_login": []interface{}{map[string]interface{}{"login_name": "l", "password": "login-password"}}},
+			info:     express,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factory := &serverInfoFactory{info: tt.info, err: tt.err, hosts: map[string]bool{}}
+			meta := sqlserverProvider{factory: factory, host: "sql1.example.com", port: "1433"}
+
+			_, err := tt.resource.Diff(context.Background(), tt.state, terraform.NewResourceConfigRaw(tt.config), meta)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Diff() error = %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Diff() error = %v, want %q", err, tt.wantErr)
+			}
+			// the diff of a new resource is customized twice by the SDK
+			if len(factory.hosts) > 1 || (tt.wantHost == "") != (len(factory.hosts) == 0) || (tt.wantHost != "" && !factory.hosts[tt.wantHost]) {
+				t.Fatalf("GetServerInfo() called for %v, want %q", factory.hosts, tt.wantHost)
+			}
+		})
+	}
+}
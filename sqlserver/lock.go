@@ -0,0 +1,39 @@
+package sqlserver
+
+import (
+	"context"
+
+	"terraform-provider-sqlserver/sqlserver/model"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+type LockConnector interface {
+	Lock(ctx context.Context) (func(), error)
+}
+
+// applicationLock holds the application lock of the server of the resource
+// during write operations, if the provider has an application_lock block.
+func applicationLock(resourceType, operation string, f resourceFunc) resourceFunc {
+	return func(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		if !meta.(sqlserverProvider).options.ApplicationLock.Enabled {
+			return f(ctx, data, meta)
+		}
+
+		connector, err := meta.(model.Provider).GetConnector(data)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		unlock, err := connector.(LockConnector).Lock(ctx)
+		if err != nil {
+			if operation == "update" {
+				data.Partial(true)
+			}
+			return diag.FromErr(err)
+		}
+		defer unlock()
+
+		return f(ctx, data, meta)
+	}
+}
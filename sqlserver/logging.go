@@ -0,0 +1,100 @@
+package sqlserver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"terraform-provider-sqlserver/sql"
+	"terraform-provider-sqlserver/sqlserver/model"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// secretFieldKeys are the log fields whose values are always masked.
+var secretFieldKeys = []string{"password", "client_secret", "client_certificate", "access_token", "token"}
+
+// resourceLogger writes to the tflog subsystem of a resource. The level of a
+// subsystem is set with e.g. TF_LOG_PROVIDER_SQLSERVER_LOGIN=DEBUG.
+type resourceLogger struct {
+	ctx       context.Context
+	subsystem string
+}
+
+func (l *resourceLogger) Debugf(format string, args ...interface{}) {
+	tflog.SubsystemDebug(l.ctx, l.subsystem, fmt.Sprintf(format, args...))
+}
+
+func (l *resourceLogger) Infof(format string, args ...interface{}) {
+	tflog.SubsystemInfo(l.ctx, l.subsystem, fmt.Sprintf(format, args...))
+}
+
+// mask hides the secrets of the values in all logs written with the returned
+// context, including the statements logged by the connector.
+func (l *resourceLogger) mask(values ...interface{}) context.Context {
+	l.ctx = maskSecrets(l.ctx, l.subsystem, secretsOf(values...))
+	return l.ctx
+}
+
+// newLogSubsystem adds the subsystem and the subsystem of the connector to ctx,
+// masking the secrets in both.
+func newLogSubsystem(ctx context.Context, subsystem string, secrets []string) context.Context {
+	for _, name := range []string{subsystem, sql.LogSubsystem} {
+		ctx = tflog.NewSubsystem(ctx, name, tflog.WithLevelFromEnv("TF_LOG_PROVIDER_SQLSERVER", strings.ToUpper(name)))
+		ctx = tflog.SubsystemMaskFieldValuesWithFieldKeys(ctx, name, secretFieldKeys...)
+	}
+	ctx = tflog.MaskFieldValuesWithFieldKeys(ctx, secretFieldKeys...)
+
+	return maskSecrets(ctx, subsystem, secrets)
+}
+
+func maskSecrets(ctx context.Context, subsystem string, secrets []string) context.Context {
+	if len(secrets) == 0 {
+		return ctx
+	}
+	for _, name := range []string{subsystem, sql.LogSubsystem} {
+		ctx = tflog.SubsystemMaskLogStrings(ctx, name, secrets...)
+	}
+	return tflog.MaskLogStrings(ctx, secrets...)
+}
+
+// secretsOf returns the passwords, client secrets and tokens of logins and users.
+func secretsOf(values ...interface{}) []string {
+	var secrets []string
+	for _, value := range values {
+		switch v := value.(type) {
+		case model.SqlLogin:
+			secrets = append(secrets, v.Password)
+		case model.AzureLogin:
+			secrets = append(secrets, v.ClientSecret, v.ClientCertificate, v.ClientCertificatePassword)
+		case model.AccessToken:
+			secrets = append(secrets, v.Token)
+		case model.User:
+			secrets = append(secrets, v.Password)
+		case *model.User:
+			if v != nil {
+				secrets = append(secrets, v.Password)
+			}
+		case *model.SSHTunnel:
+			if v != nil {
+				secrets = append(secrets, v.PrivateKey, v.PrivateKeyPassphrase)
+			}
+		}
+	}
+
+	nonEmpty := secrets[:0]
+	for _, secret := range secrets {
+		if secret != "" {
+			nonEmpty = append(nonEmpty, secret)
+		}
+	}
+	return nonEmpty
+}
+
+func loggerFromMeta(ctx context.Context, meta interface{}, data *schema.ResourceData, resource, function string) (context.Context, *resourceLogger) {
+	ctx = meta.(model.Provider).LogContext(ctx, data, resource)
+	ctx = tflog.SubsystemSetField(ctx, resource, "function", function)
+	ctx = sql.WithOperation(ctx, sql.Operation{ResourceType: "sqlserver_" + resource, ResourceID: data.Id(), Operation: function})
+	return ctx, &resourceLogger{ctx: ctx, subsystem: resource}
+}
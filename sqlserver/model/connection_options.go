@@ -0,0 +1,98 @@
+package model
+
+import "time"
+
+// ConnectionOptions holds the provider wide settings that apply to every
+// connection handed out by a ConnectorFactory.
+type ConnectionOptions struct {
+	Pool          ConnectionPool
+	TLS           TLS
+	Environment   Environment
+	Retry         Retry
+	LogStatements bool
+	AuditLogPath  string
+	DryRun        DryRun
+	ReadOnly      bool
+	Session       Session
+	SSHTunnel     *SSHTunnel
+	// Parameters are passed through to the driver with every connection.
+	Parameters      map[string]string
+	ApplicationLock ApplicationLock
+	// ConnectTimeout bounds the connect of every operation, if set.
+	ConnectTimeout time.Duration
+	// ExecuteAs is the principal the statements are executed as, if set.
+	ExecuteAs *ExecuteAs
+	// CredentialHelper is the command fetching the password or client secret
+	// of logins without one, if set.
+	CredentialHelper []string
+}
+
+// ExecuteAs impersonates a LOGIN or USER with EXECUTE AS.
+type ExecuteAs struct {
+	Type string
+	Name string
+}
+
+// ApplicationLock is taken with sp_getapplock in master around every write
+// operation.
+type ApplicationLock struct {
+	Enabled  bool
+	Resource string
+	Timeout  time.Duration
+}
+
+// SSHTunnel dials the connections through a bastion host, authenticating with
+// PrivateKey or the SSH agent.
+type SSHTunnel struct {
+	Host                 string
+	Port                 string
+	User                 string
+	PrivateKey           string
+	PrivateKeyPassphrase string
+	Agent                bool
+	KnownHostsFile       string
+}
+
+// Session identifies the sessions of the provider. Context is set with
+// sp_set_session_context before every write; nil disables it.
+type Session struct {
+	ApplicationName string
+	WorkstationID   string
+	Context         map[string]string
+}
+
+// DryRun renders the statements of write operations instead of executing them,
+// appending them to ScriptPath if set.
+type DryRun struct {
+	Enabled    bool
+	ScriptPath string
+}
+
+type ConnectionPool struct {
+	MaxOpenConnections    int
+	MaxIdleConnections    int
+	ConnectionMaxLifetime time.Duration
+}
+
+type TLS struct {
+	Mode                   string
+	CAFile                 string
+	ServerName             string
+	MinVersion             string
+	TrustServerCertificate bool
+}
+
+// Environment selects the Azure cloud of the Azure AD login methods. AuthorityHost
+// and TokenAudience override the values of the cloud.
+type Environment struct {
+	Name          string
+	AuthorityHost string
+	TokenAudience string
+}
+
+// Retry configures how often statements failing with a transient error are
+// tried and the maximum delay between the attempts.
+type Retry struct {
+	MaxAttempts int
+	MaxBackoff  time.Duration
+}
@@ -1,7 +1,18 @@
 package model
 
-import "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+import (
+  "context"
+
+  "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
 
 type ConnectorFactory interface {
-  GetConnector(data *schema.ResourceData, host string, port string, login interface{}) (interface{}, error)
+  // GetConnector returns a connector for the server. If instance is set and port
+  // is empty, the port of the named instance is resolved by the SQL Server Browser.
+  GetConnector(data *schema.ResourceData, host string, port string, instance string, login interface{}, options ConnectionOptions) (interface{}, error)
+  // GetServerInfo returns the edition and version of the server, cached per server.
+  GetServerInfo(ctx context.Context, host string, port string, instance string, login interface{}, options ConnectionOptions) (*ServerInfo, error)
+  // CredentialSecrets returns the passwords and client secrets fetched by the
+  // credential helper so far, so they are masked in the logs.
+  CredentialSecrets() []string
 }
@@ -13,11 +13,29 @@ type SqlLogin struct {
 }
 
 type AzureLogin struct {
-  TenantID     string
-  ClientID     string
-  ClientSecret string
+  TenantID                  string
+  ClientID                  string
+  ClientSecret              string
+  ClientCertificatePath     string
+  ClientCertificate         string
+  ClientCertificatePassword string
 }
 
 type FedauthMSI struct {
   UserID string
-}
\ No newline at end of file
+}
+
+type FedauthDefault struct {
+}
+
+type FedauthWorkloadIdentity struct {
+  TenantID      string
+  ClientID      string
+  TokenFilePath string
+  AuthorityHost string
+}
+
+type AccessToken struct {
+  Token   string
+  Command []string
+}
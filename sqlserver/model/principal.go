@@ -0,0 +1,12 @@
+package model
+
+// Principal is the server principal the provider is authenticated as.
+type Principal struct {
+	Name string
+	// ServerPermissions tells whether the principal holds each of the requested
+	// server permissions.
+	ServerPermissions map[string]bool
+	// MissingDatabasePermissions lists per requested database permission the
+	// online databases the principal can access without holding it.
+	MissingDatabasePermissions map[string][]string
+}
@@ -1,12 +1,17 @@
 package model
 
 import (
+  "context"
+
   "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-  "github.com/rs/zerolog"
 )
 
 type Provider interface {
   GetConnector(data *schema.ResourceData) (interface{}, error)
-  ResourceLogger(resource, function string) zerolog.Logger
-  DataSourceLogger(datasource, function string) zerolog.Logger
+  // GetServerInfo returns the edition and version of the server of the resource,
+  // or nil if the server is not known yet.
+  GetServerInfo(ctx context.Context, diff *schema.ResourceDiff) (*ServerInfo, error)
+  // LogContext returns ctx with the tflog subsystem of the resource, masking
+  // the secrets of the logins used for it.
+  LogContext(ctx context.Context, data *schema.ResourceData, subsystem string) context.Context
 }
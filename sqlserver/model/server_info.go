@@ -0,0 +1,10 @@
+package model
+
+// ServerInfo describes the engine of a server, as returned by SERVERPROPERTY.
+type ServerInfo struct {
+	// EngineEdition is SERVERPROPERTY('EngineEdition'), e.g. 3 for the
+	// Enterprise and Developer editions or 5 for Azure SQL Database.
+	EngineEdition  int
+	Edition        string
+	ProductVersion string
+}
@@ -3,29 +3,38 @@ package sqlserver
 import (
 	"context"
 	"fmt"
-	"io"
-	"os"
 	"strings"
 	"terraform-provider-sqlserver/sql"
 	"terraform-provider-sqlserver/sqlserver/model"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/pkg/errors"
 )
 
 type sqlserverProvider struct {
-	factory model.ConnectorFactory
-	logger  *zerolog.Logger
-	host    string
-	port    string
-	login   interface{}
+	factory  model.ConnectorFactory
+	host     string
+	port     string
+	instance string
+	login    interface{}
+	options  model.ConnectionOptions
 }
 
 const (
-	providerLogFile = "terraform-provider-sqlserver.log"
+	defaultMaxOpenConnections    = 10
+	defaultMaxIdleConnections    = 5
+	defaultConnectionMaxLifetime = "30m"
+
+	defaultRetryMaxAttempts = 3
+	defaultRetryMaxBackoff  = "30s"
+
+	defaultApplicationName = "terraform-provider-sqlserver"
+
+	defaultLockTimeout = "5m"
 )
 
 var (
@@ -38,21 +47,51 @@ func New(version, commit string) func() *schema.Provider {
 	}
 }
 
+var TLSModes = []string{
+	"disable",
+	"optional",
+	"required",
+	"strict",
+}
+
+var Environments = []string{
+	"public",
+	"usgovernment",
+	"china",
+	"custom",
+}
+
 var LoginMethods = []string{
 	"login",
 	"azure_login",
 	"azuread_default_chain_auth",
 	"azuread_managed_identity_auth",
+	"azuread_workload_identity_auth",
+	"access_token",
+	"access_token_command",
 }
 
 func Provider(factory model.ConnectorFactory) *schema.Provider {
-	return &schema.Provider{
+	provider := &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"debug": {
 				Type:        schema.TypeBool,
-				Description: fmt.Sprintf("Enable provider debug logging (logs to file %s)", providerLogFile),
+				Description: "Enable provider debug logging",
 				Optional:    true,
 				Default:     false,
+				Deprecated:  "Provider logs are written to the Terraform log, use TF_LOG_PROVIDER=DEBUG instead",
+			},
+			"log_statements": {
+				Type:        schema.TypeBool,
+				Description: "Log every executed T-SQL statement at debug level, with secrets redacted",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("TF_SQLSERVER_LOG_STATEMENTS", false),
+			},
+			"audit_log_path": {
+				Type:        schema.TypeString,
+				Description: "Path of a file every executed DDL statement is appended to as a JSON line, with secrets redacted",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("TF_SQLSERVER_AUDIT_LOG_PATH", nil),
 			},
 			"host": {
 				Type:        schema.TypeString,
@@ -67,73 +106,311 @@ func Provider(factory model.ConnectorFactory) *schema.Provider {
 				Type:        schema.TypeString,
 				Optional:    true,
 				ForceNew:    true,
-				DefaultFunc: schema.EnvDefaultFunc("TF_SQLSERVER_PORT", DefaultPort),
-				Default:     DefaultPort,
+				Description: "The port of the SQL Server. Defaults to 1433, or to the port of `instance` resolved by the SQL Server Browser.",
+				DefaultFunc: schema.EnvDefaultFunc("TF_SQLSERVER_PORT", nil),
 			},
-			"login": {
-				Type:          schema.TypeList,
-				MaxItems:      1,
+			"instance": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The name of a named instance, e.g. `SQLEXPRESS` for `HOST\\SQLEXPRESS`.",
+				DefaultFunc: schema.EnvDefaultFunc("TF_SQLSERVER_INSTANCE", nil),
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return strings.EqualFold(old, new)
+				},
+			},
+			"connection_string": {
+				Type:          schema.TypeString,
 				Optional:      true,
-				ConflictsWith: []string{"azure_login", "azuread_default_chain_auth", "azuread_managed_identity_auth"},
+				Sensitive:     true,
+				DefaultFunc:   schema.EnvDefaultFunc("TF_SQLSERVER_CONNECTION_STRING", nil),
+				ConflictsWith: []string{"host", "port", "instance", "connection_parameters"},
+				Description:   "A connection string of the SQL Server in the URL, ADO or ODBC format, instead of `host`, `port` and `instance`.",
+			},
+			"connection_parameters": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Sensitive:   true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Parameters of the driver added to every connection, e.g. `packet size` or `ApplicationIntent`.",
+			},
+			"environment": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Description:      "The Azure cloud of the Azure AD login methods: `public`, `usgovernment`, `china` or `custom`.",
+				DefaultFunc:      schema.EnvDefaultFunc("TF_SQLSERVER_ENVIRONMENT", "public"),
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice(Environments, false)),
+			},
+			"authority_host": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Description:      "The Azure AD authority host, overriding the one of `environment`. Required for the `custom` environment.",
+				DefaultFunc:      schema.EnvDefaultFunc("TF_SQLSERVER_AUTHORITY_HOST", nil),
+				ValidateDiagFunc: validation.ToDiagFunc(validation.IsURLWithHTTPS),
+			},
+			"token_audience": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The audience of the Azure AD access tokens, overriding the SQL Database endpoint of `environment`. Required for the `custom` environment.",
+				DefaultFunc: schema.EnvDefaultFunc("TF_SQLSERVER_TOKEN_AUDIENCE", nil),
+			},
+			"connection_pool": {
+				Type:        schema.TypeList,
+				MaxItems:    1,
+				Optional:    true,
+				Description: "Settings of the connection pool shared by all resources of this provider.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"max_open_connections": {
+							Type:             schema.TypeInt,
+							Optional:         true,
+							Default:          defaultMaxOpenConnections,
+							Description:      "Maximum number of open connections per server, database and login. 0 means unlimited.",
+							ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(0)),
+						},
+						"max_idle_connections": {
+							Type:             schema.TypeInt,
+							Optional:         true,
+							Default:          defaultMaxIdleConnections,
+							Description:      "Maximum number of idle connections kept per server, database and login.",
+							ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(0)),
+						},
+						"connection_max_lifetime": {
+							Type:             schema.TypeString,
+							Optional:         true,
+							Default:          defaultConnectionMaxLifetime,
+							Description:      "Maximum amount of time a connection may be reused, e.g. `30m`. `0s` means connections are reused forever.",
+							ValidateDiagFunc: validation.ToDiagFunc(validateDuration),
+						},
+					},
+				},
+			},
+			"dry_run": {
+				Type:        schema.TypeList,
+				MaxItems:    1,
+				Optional:    true,
+				Description: "Render the T-SQL of creates, updates and deletes instead of executing it. Reads still run.",
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
-						"username": {
+						"enabled": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     true,
+							Description: "Whether the dry run is enabled.",
+						},
+						"script_path": {
 							Type:        schema.TypeString,
 							Optional:    true,
-							DefaultFunc: schema.EnvDefaultFunc("TF_SQLSERVER_USERNAME", nil),
+							Description: "Path of a file the rendered statements are appended to.",
 						},
-						"password": {
+					},
+				},
+			},
+			"application_name": {
+				Type:        schema.TypeString,
+				Description: "The application name of the sessions of the provider, shown as `program_name` in `sys.dm_exec_sessions`. Must not be empty, as the sessions killed to reconfigure Resource Governor are told apart from those of the provider by it.",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("TF_SQLSERVER_APPLICATION_NAME", defaultApplicationName),
+				// without an application name, the sessions of the provider, including
+				// the one holding the application lock, would be killed
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotEmpty),
+			},
+			"workstation_id": {
+				Type:        schema.TypeString,
+				Description: "The workstation ID of the sessions of the provider, shown as `host_name` in `sys.dm_exec_sessions`. Defaults to the host name of the machine running Terraform.",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("TF_SQLSERVER_WORKSTATION_ID", nil),
+			},
+			"session_context": {
+				Type:        schema.TypeList,
+				MaxItems:    1,
+				Optional:    true,
+				Description: "Set the Terraform run, workspace and resource with `sp_set_session_context` before every create, update and delete, for server-side audits and triggers.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"run_id": {
 							Type:        schema.TypeString,
 							Optional:    true,
-							Sensitive:   true,
-							DefaultFunc: schema.EnvDefaultFunc("TF_SQLSERVER_PASSWORD", nil),
+							DefaultFunc: schema.MultiEnvDefaultFunc([]string{"TF_SQLSERVER_RUN_ID", "TFC_RUN_ID"}, nil),
+							Description: "The ID of the Terraform run, set as `terraform.run_id`.",
+						},
+						"workspace": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							DefaultFunc: schema.MultiEnvDefaultFunc([]string{"TF_SQLSERVER_WORKSPACE", "TFC_WORKSPACE_NAME", "TF_WORKSPACE"}, nil),
+							Description: "The Terraform workspace, set as `terraform.workspace`.",
+						},
+						"values": {
+							Type:        schema.TypeMap,
+							Optional:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Additional keys and values of the session context.",
 						},
 					},
 				},
 			},
-			"azure_login": {
-				Type:          schema.TypeList,
-				MaxItems:      1,
-				Optional:      true,
-				ConflictsWith: []string{"login", "azuread_default_chain_auth", "azuread_managed_identity_auth"},
+			"ssh_tunnel": {
+				Type:        schema.TypeList,
+				MaxItems:    1,
+				Optional:    true,
+				Description: "Connect to the SQL Servers through an SSH bastion host.",
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
-						"tenant_id": {
+						"host": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The host name of the bastion host.",
+						},
+						"port": {
 							Type:        schema.TypeString,
 							Optional:    true,
-							DefaultFunc: schema.EnvDefaultFunc("TF_SQLSERVER_TENANT_ID", nil),
+							Default:     "22",
+							Description: "The SSH port of the bastion host.",
 						},
-						"client_id": {
+						"user": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The user to log in to the bastion host with.",
+						},
+						"private_key": {
 							Type:        schema.TypeString,
 							Optional:    true,
-							DefaultFunc: schema.EnvDefaultFunc("TF_SQLSERVER_CLIENT_ID", nil),
+							Sensitive:   true,
+							DefaultFunc: schema.EnvDefaultFunc("TF_SQLSERVER_SSH_PRIVATE_KEY", nil),
+							Description: "The PEM encoded private key of the user.",
 						},
-						"client_secret": {
+						"private_key_passphrase": {
 							Type:        schema.TypeString,
 							Optional:    true,
 							Sensitive:   true,
-							DefaultFunc: schema.EnvDefaultFunc("TF_SQLSERVER_CLIENT_SECRET", nil),
+							DefaultFunc: schema.EnvDefaultFunc("TF_SQLSERVER_SSH_PRIVATE_KEY_PASSPHRASE", nil),
+							Description: "The passphrase of the private key.",
+						},
+						"agent": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Authenticate with the keys of the SSH agent listening on `SSH_AUTH_SOCK`.",
+						},
+						"known_hosts_file": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "~/.ssh/known_hosts",
+							Description: "The known hosts file verifying the host key of the bastion host.",
 						},
 					},
 				},
 			},
-			"azuread_default_chain_auth": {
-				Type:          schema.TypeList,
-				MaxItems:      1,
-				Optional:      true,
-				ConflictsWith: []string{"login", "azure_login", "azuread_managed_identity_auth"},
-				Elem:          &schema.Resource{},
+			"read_only": {
+				Type:        schema.TypeBool,
+				Description: "Fail every create, update and delete, and refuse statements that could change a server. For plans with a principal that may only view the servers.",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("TF_SQLSERVER_READ_ONLY", false),
 			},
-			"azuread_managed_identity_auth": {
-				Type:          schema.TypeList,
-				MaxItems:      1,
-				Optional:      true,
-				ConflictsWith: []string{"login", "azure_login", "azuread_default_chain_auth"},
+			executeAsProp: getExecuteAsSchema("Execute the statements of create, update and delete as another principal with EXECUTE AS, reverted after every statement."),
+			"credential_helper": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MinItems:    1,
+				Description: "A command and its arguments that fetches the `password` of `login` or the `client_secret` of `azure_login` when connecting, if they are not set. The command is called with the argument `get`, reads a JSON object describing the login from stdin and writes a JSON object with the secret to stdout.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"validate_on_configure": {
+				Type:        schema.TypeBool,
+				Description: "Connect to the server when the provider is configured, failing if it cannot, and warn about the permissions the login does not hold.",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("TF_SQLSERVER_VALIDATE_ON_CONFIGURE", false),
+			},
+			"connect_timeout": {
+				Type:             schema.TypeString,
+				Description:      "Maximum time to connect to the server, e.g. `30s`. The connect is also bounded by the timeout of the operation of the resource.",
+				Optional:         true,
+				DefaultFunc:      schema.EnvDefaultFunc("TF_SQLSERVER_CONNECT_TIMEOUT", nil),
+				ValidateDiagFunc: validation.ToDiagFunc(validateDuration),
+			},
+			"retry": {
+				Type:        schema.TypeList,
+				MaxItems:    1,
+				Optional:    true,
+				Description: "Retries of statements and connects failing with a transient error, e.g. during an Azure SQL failover.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"max_attempts": {
+							Type:             schema.TypeInt,
+							Optional:         true,
+							Default:          defaultRetryMaxAttempts,
+							Description:      "Maximum number of attempts of a statement. 1 disables retries.",
+							ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(1)),
+						},
+						"max_backoff": {
+							Type:             schema.TypeString,
+							Optional:         true,
+							Default:          defaultRetryMaxBackoff,
+							Description:      "Maximum delay between two attempts, e.g. `30s`.",
+							ValidateDiagFunc: validation.ToDiagFunc(validateDuration),
+						},
+					},
+				},
+			},
+			"application_lock": {
+				Type:        schema.TypeList,
+				MaxItems:    1,
+				Optional:    true,
+				Description: "Take an application lock in master around every create, update and delete, so that concurrent applies to a server are serialized.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"resource": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     sql.DefaultLockResource,
+							Description: "The name of the application lock.",
+						},
+						"timeout": {
+							Type:             schema.TypeString,
+							Optional:         true,
+							Default:          defaultLockTimeout,
+							Description:      "Maximum time to wait for the lock, e.g. `5m`. `0s` waits forever.",
+							ValidateDiagFunc: validation.ToDiagFunc(validateDuration),
+						},
+					},
+				},
+			},
+			"tls": {
+				Type:        schema.TypeList,
+				MaxItems:    1,
+				Optional:    true,
+				Description: "Encryption settings of the connections to SQL Server.",
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
-						"user_id": {
-							Type:     schema.TypeString,
-							Optional: true,
+						"mode": {
+							Type:             schema.TypeString,
+							Optional:         true,
+							Description:      "The encryption mode: `disable`, `optional`, `required` or `strict` (TDS 8.0).",
+							ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice(TLSModes, false)),
+						},
+						"ca_file": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Path to a PEM file with the certificate authorities used to verify the server certificate.",
+						},
+						"server_name": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "The host name expected in the server certificate, if it differs from `host`.",
+						},
+						"min_version": {
+							Type:             schema.TypeString,
+							Optional:         true,
+							Description:      "The minimum TLS version: `1.0`, `1.1`, `1.2` or `1.3`.",
+							ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{"1.0", "1.1", "1.2", "1.3"}, false)),
+						},
+						"trust_server_certificate": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Accept any server certificate without verification. Only use this for development servers with self-signed certificates.",
 						},
 					},
 				},
@@ -152,70 +429,346 @@ func Provider(factory model.ConnectorFactory) *schema.Provider {
 			return providerConfigure(ctx, data, factory)
 		},
 	}
+
+	for name, loginSchema := range getLoginSchemas("", true) {
+		provider.Schema[name] = loginSchema
+	}
+
+	wrapWriteFunctions(provider.ResourcesMap, applicationLock)
+	wrapWriteFunctions(provider.ResourcesMap, dryRun)
+	wrapWriteFunctions(provider.ResourcesMap, readOnly)
+
+	return provider
 }
 
 func providerConfigure(ctx context.Context, data *schema.ResourceData, factory model.ConnectorFactory) (model.Provider, diag.Diagnostics) {
-	isDebug := data.Get("debug").(bool)
 	host := data.Get("host").(string)
+	instance := data.Get("instance").(string)
 	port := data.Get("port").(string)
-	logger := newLogger(isDebug)
-
-	var login interface{}
-	if admin, ok := data.GetOk("login"); ok {
-		admin := admin.([]interface{})
-		adminMap := admin[0].(map[string]interface{})
-		login = model.SqlLogin{
-			Username: adminMap["username"].(string),
-			Password: adminMap["password"].(string),
+
+	login := loginFromData(data, "")
+
+	parameters, err := getConnectionParameters(data)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+
+	if v := getConnectionString(data); v != "" {
+		connectionString, err := sql.ParseConnectionString(v)
+		if err != nil {
+			return nil, diag.FromErr(err)
 		}
+		host, port, instance = connectionString.Host, connectionString.Port, connectionString.Instance
+		// the login blocks take precedence over the login of the connection string
+		if login == nil && connectionString.Username != "" {
+			login = model.SqlLogin{
+				Username: connectionString.Username,
+				Password: connectionString.Password,
+			}
+		}
+		parameters = connectionString.Parameters
+	}
+	port = defaultPort(port, instance)
+
+	pool, err := getConnectionPool(data)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+
+	environment, err := getEnvironment(data)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+
+	retry, err := getRetry(data)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+
+	sshTunnel, err := getSSHTunnel(data)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+
+	applicationLock, err := getApplicationLock(data)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+
+	connectTimeout, err := getConnectTimeout(data)
+	if err != nil {
+		return nil, diag.FromErr(err)
 	}
 
-	if admin, ok := data.GetOk("azure_login"); ok {
-		admin := admin.([]interface{})
-		adminMap := admin[0].(map[string]interface{})
-		login = model.AzureLogin{
-			TenantID:     adminMap["tenant_id"].(string),
-			ClientID:     adminMap["client_id"].(string),
-			ClientSecret: adminMap["client_secret"].(string),
+	options := model.ConnectionOptions{
+		Pool:            pool,
+		TLS:             getTLS(data),
+		Environment:     environment,
+		Retry:           retry,
+		LogStatements:   data.Get("log_statements").(bool),
+		AuditLogPath:    data.Get("audit_log_path").(string),
+		DryRun:          getDryRun(data),
+		ReadOnly:        data.Get("read_only").(bool),
+		Session:         getSession(data),
+		SSHTunnel:       sshTunnel,
+		Parameters:      parameters,
+		ApplicationLock: applicationLock,
+		ConnectTimeout:  connectTimeout,
+		ExecuteAs:       executeAsFromData(data),
+	}
+	for _, arg := range data.Get("credential_helper").([]interface{}) {
+		options.CredentialHelper = append(options.CredentialHelper, arg.(string))
+	}
+
+	var diags diag.Diagnostics
+	if data.Get("validate_on_configure").(bool) {
+		diags = validateProvider(ctx, data, factory, host, port, instance, login, options)
+		if diags.HasError() {
+			return nil, diags
 		}
 	}
 
-	if admin, ok := data.GetOk("azuread_managed_identity_auth"); ok {
-		admin := admin.([]interface{})
-		adminMap := admin[0].(map[string]interface{})
-		login = model.FedauthMSI{
-			UserID: adminMap["user_id"].(string),
+	tflog.Info(ctx, fmt.Sprintf("Created provider with %s", sql.ServerName(host, port, instance)))
+
+	return sqlserverProvider{factory: factory, host: host, port: port, instance: instance, login: login, options: options}, diags
+}
+
+func getConnectTimeout(data *schema.ResourceData) (time.Duration, error) {
+	v, ok := data.GetOk("connect_timeout")
+	if !ok {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(v.(string))
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid connect_timeout")
+	}
+	return timeout, nil
+}
+
+func getConnectionPool(data *schema.ResourceData) (model.ConnectionPool, error) {
+	pool := model.ConnectionPool{
+		MaxOpenConnections: defaultMaxOpenConnections,
+		MaxIdleConnections: defaultMaxIdleConnections,
+	}
+	lifetime := defaultConnectionMaxLifetime
+
+	if v, ok := data.GetOk("connection_pool"); ok {
+		poolMap := v.([]interface{})[0].(map[string]interface{})
+		pool.MaxOpenConnections = poolMap["max_open_connections"].(int)
+		pool.MaxIdleConnections = poolMap["max_idle_connections"].(int)
+		lifetime = poolMap["connection_max_lifetime"].(string)
+	}
+
+	var err error
+	if pool.ConnectionMaxLifetime, err = time.ParseDuration(lifetime); err != nil {
+		return pool, err
+	}
+
+	return pool, nil
+}
+
+func getRetry(data *schema.ResourceData) (model.Retry, error) {
+	retry := model.Retry{
+		MaxAttempts: defaultRetryMaxAttempts,
+	}
+	maxBackoff := defaultRetryMaxBackoff
+
+	if v, ok := data.GetOk("retry"); ok {
+		retryMap := v.([]interface{})[0].(map[string]interface{})
+		retry.MaxAttempts = retryMap["max_attempts"].(int)
+		maxBackoff = retryMap["max_backoff"].(string)
+	}
+
+	var err error
+	if retry.MaxBackoff, err = time.ParseDuration(maxBackoff); err != nil {
+		return retry, err
+	}
+
+	return retry, nil
+}
+
+func getApplicationLock(data *schema.ResourceData) (model.ApplicationLock, error) {
+	var lock model.ApplicationLock
+	v, ok := data.GetOk("application_lock")
+	if !ok {
+		return lock, nil
+	}
+
+	lock.Enabled = true
+	lock.Resource = sql.DefaultLockResource
+	timeout := defaultLockTimeout
+	if lockMap, ok := v.([]interface{})[0].(map[string]interface{}); ok {
+		lock.Resource = lockMap["resource"].(string)
+		timeout = lockMap["timeout"].(string)
+	}
+
+	var err error
+	if lock.Timeout, err = time.ParseDuration(timeout); err != nil {
+		return lock, err
+	}
+	return lock, nil
+}
+
+func getDryRun(data *schema.ResourceData) model.DryRun {
+	var dryRun model.DryRun
+	if v, ok := data.GetOk("dry_run"); ok {
+		dryRunMap := v.([]interface{})[0].(map[string]interface{})
+		dryRun.Enabled = dryRunMap["enabled"].(bool)
+		dryRun.ScriptPath = dryRunMap["script_path"].(string)
+	}
+	return dryRun
+}
+
+func getSession(data *schema.ResourceData) model.Session {
+	session := model.Session{
+		ApplicationName: data.Get("application_name").(string),
+		WorkstationID:   data.Get("workstation_id").(string),
+	}
+	if v, ok := data.GetOk("session_context"); ok {
+		session.Context = map[string]string{}
+		// the block may be empty, which sets the keys of the resource only
+		if contextMap, ok := v.([]interface{})[0].(map[string]interface{}); ok {
+			for key, value := range contextMap["values"].(map[string]interface{}) {
+				session.Context[key] = value.(string)
+			}
+			if runID := contextMap["run_id"].(string); runID != "" {
+				session.Context["terraform.run_id"] = runID
+			}
+			if workspace := contextMap["workspace"].(string); workspace != "" {
+				session.Context["terraform.workspace"] = workspace
+			}
 		}
 	}
+	return session
+}
 
-	logger.Info().Msgf("Created provider with %s:%s", host, port)
+// getConnectionString returns the connection string of the provider. The
+// connection string of TF_SQLSERVER_CONNECTION_STRING is ignored if the
+// configuration sets the server or connection_parameters, as ConflictsWith only
+// checks the configuration.
+func getConnectionString(data *schema.ResourceData) string {
+	connectionString := data.Get("connection_string").(string)
+	config := data.GetRawConfig()
+	if connectionString == "" || config.IsNull() || !config.IsKnown() {
+		return connectionString
+	}
+	if config.Type().HasAttribute("connection_string") && !config.GetAttr("connection_string").IsNull() {
+		return connectionString
+	}
+	for _, name := range []string{"host", "port", "instance", "connection_parameters"} {
+		if config.Type().HasAttribute(name) && !config.GetAttr(name).IsNull() {
+			return ""
+		}
+	}
+	return connectionString
+}
 
-	return sqlserverProvider{factory: factory, logger: logger, host: host, port: port, login: login}, nil
+func getConnectionParameters(data *schema.ResourceData) (map[string]string, error) {
+	v, ok := data.GetOk("connection_parameters")
+	if !ok {
+		return nil, nil
+	}
+	parameters := map[string]string{}
+	for key, value := range v.(map[string]interface{}) {
+		parameters[key] = value.(string)
+	}
+	parameters, err := sql.ValidateConnectionParameters(parameters)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid connection_parameters")
+	}
+	return parameters, nil
 }
 
-func (p sqlserverProvider) GetConnector(data *schema.ResourceData) (interface{}, error) {
-	return p.factory.GetConnector(data, p.host, p.port, p.login)
+func getSSHTunnel(data *schema.ResourceData) (*model.SSHTunnel, error) {
+	v, ok := data.GetOk("ssh_tunnel")
+	if !ok {
+		return nil, nil
+	}
+	tunnelMap := v.([]interface{})[0].(map[string]interface{})
+	tunnel := &model.SSHTunnel{
+		Host:                 tunnelMap["host"].(string),
+		Port:                 tunnelMap["port"].(string),
+		User:                 tunnelMap["user"].(string),
+		PrivateKey:           tunnelMap["private_key"].(string),
+		PrivateKeyPassphrase: tunnelMap["private_key_passphrase"].(string),
+		Agent:                tunnelMap["agent"].(bool),
+		KnownHostsFile:       tunnelMap["known_hosts_file"].(string),
+	}
+	if tunnel.PrivateKey == "" && !tunnel.Agent {
+		return nil, errors.New("ssh_tunnel requires private_key or agent")
+	}
+	return tunnel, nil
 }
 
-func (p sqlserverProvider) ResourceLogger(resource, function string) zerolog.Logger {
-	return p.logger.With().Str("resource", resource).Str("func", function).Logger()
+func getTLS(data *schema.ResourceData) model.TLS {
+	var tls model.TLS
+	if v, ok := data.GetOk("tls"); ok {
+		tlsMap := v.([]interface{})[0].(map[string]interface{})
+		tls.Mode = tlsMap["mode"].(string)
+		tls.CAFile = tlsMap["ca_file"].(string)
+		tls.ServerName = tlsMap["server_name"].(string)
+		tls.MinVersion = tlsMap["min_version"].(string)
+		tls.TrustServerCertificate = tlsMap["trust_server_certificate"].(bool)
+	}
+	return tls
 }
 
-func (p sqlserverProvider) DataSourceLogger(datasource, function string) zerolog.Logger {
-	return p.logger.With().Str("datasource", datasource).Str("func", function).Logger()
+func getEnvironment(data *schema.ResourceData) (model.Environment, error) {
+	environment := model.Environment{
+		Name:          data.Get("environment").(string),
+		AuthorityHost: data.Get("authority_host").(string),
+		TokenAudience: data.Get("token_audience").(string),
+	}
+	if environment.Name == "custom" && (environment.AuthorityHost == "" || environment.TokenAudience == "") {
+		return environment, errors.New("authority_host and token_audience are required for the custom environment")
+	}
+	return environment, nil
 }
 
-func newLogger(isDebug bool) *zerolog.Logger {
-	var writer io.Writer = nil
-	logLevel := zerolog.Disabled
-	if isDebug {
-		f, err := os.OpenFile(providerLogFile, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
-		if err != nil {
-			log.Err(err).Msg("error opening file")
+func (p sqlserverProvider) GetConnector(data *schema.ResourceData) (interface{}, error) {
+	host, port, instance, login := p.server(data)
+	options := p.options
+	if executeAs := executeAsFromData(data); executeAs != nil {
+		options.ExecuteAs = executeAs
+	}
+	return p.factory.GetConnector(data, host, port, instance, login, options)
+}
+
+// server returns the host, port, instance and login of the server block of the
+// resource, falling back to the provider defaults when the resource does not
+// override them.
+func (p sqlserverProvider) server(data serverData) (string, string, string, interface{}) {
+	if _, ok := data.GetOk(serverProp); !ok {
+		return p.host, p.port, p.instance, p.login
+	}
+
+	prefix := serverProp + ".0."
+	host := data.Get(prefix + "host").(string)
+	instance := data.Get(prefix + "instance").(string)
+	port := defaultPort(data.Get(prefix+"port").(string), instance)
+	login := loginFromData(data, prefix)
+	if login == nil {
+		login = p.login
+	}
+
+	return host, port, instance, login
+}
+
+func (p sqlserverProvider) GetServerInfo(ctx context.Context, diff *schema.ResourceDiff) (*model.ServerInfo, error) {
+	for _, key := range []string{"host", "port", "instance"} {
+		if !diff.NewValueKnown(serverProp + ".0." + key) {
+			return nil, nil
 		}
-		writer = f
-		logLevel = zerolog.DebugLevel
 	}
-	logger := zerolog.New(writer).Level(logLevel).With().Timestamp().Logger()
-	return &logger
+	host, port, instance, login := p.server(diff)
+	if host == "" {
+		return nil, nil
+	}
+	return p.factory.GetServerInfo(ctx, host, port, instance, login, p.options)
+}
+
+func (p sqlserverProvider) LogContext(ctx context.Context, data *schema.ResourceData, subsystem string) context.Context {
+	_, _, _, login := p.server(data)
+	secrets := append(secretsOf(p.login, login, p.options.SSHTunnel), p.factory.CredentialSecrets()...)
+	return newLogSubsystem(ctx, subsystem, secrets)
 }
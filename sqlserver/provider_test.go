@@ -13,6 +13,7 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
@@ -37,6 +38,94 @@ func TestProvider(t *testing.T) {
 	}
 }
 
+func TestProviderApplicationName(t *testing.T) {
+	tests := []struct {
+		name            string
+		applicationName string
+		wantErr         bool
+	}{
+		{
+			name:            "application name",
+			applicationName: "terraform-ci",
+		},
+		{
+			name:            "empty",
+			applicationName: "",
+			wantErr:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := terraform.NewResourceConfigRaw(map[string]interface{}{"application_name": tt.applicationName})
+			diags := Provider(sql.GetFactory()).Validate(config)
+			if diags.HasError() != tt.wantErr {
+				t.Fatalf("Validate() = %v, want error %v", diags, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProviderConnectionStringEnvironment(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   map[string]cty.Value
+		wantHost string
+		wantPort string
+	}{
+		{
+			name:     "environment",
+			wantHost: "sql2.example.com",
+			wantPort: "1434",
+		},
+		{
+			name:     "host",
+			config:   map[string]cty.Value{"host": cty.StringVal("sql1.example.com")},
+			wantHost: "sql1.example.com",
+			wantPort: "1433",
+		},
+		{
+			name:     "connection parameters",
+			config:   map[string]cty.Value{"host": cty.StringVal("sql1.example.com"), "connection_parameters": cty.MapVal(map[string]cty.Value{"packet size": cty.StringVal("8192")})},
+			wantHost: "sql1.example.com",
+			wantPort: "1433",
+		},
+		{
+			name:     "connection string",
+			config:   map[string]cty.Value{"connection_string": cty.StringVal("sqlserver://sql3.example.com:1435")},
+			wantHost: "sql3.example.com",
+			wantPort: "1435",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TF_SQLSERVER_CONNECTION_STRING", "sqlserver://sql2.example.com:1434")
+			provider := Provider(sql.GetFactory())
+
+			block := schema.InternalMap(provider.Schema).CoreConfigSchema()
+			values := map[string]cty.Value{}
+			for name, attributeType := range block.ImpliedType().AttributeTypes() {
+				values[name] = cty.NullVal(attributeType)
+			}
+			for name, value := range tt.config {
+				values[name] = value
+			}
+
+			// the raw config is set as by the gRPC server of the SDK
+			config := terraform.NewResourceConfigShimmed(cty.ObjectVal(values), block)
+			config.CtyValue = cty.ObjectVal(values)
+			if diags := provider.Configure(context.Background(), config); diags.HasError() {
+				t.Fatalf("Configure() = %v", diags)
+			}
+			got := provider.Meta().(sqlserverProvider)
+			if got.host != tt.wantHost || got.port != tt.wantPort {
+				t.Fatalf("Configure() server = %s:%s, want %s:%s", got.host, got.port, tt.wantHost, tt.wantPort)
+			}
+		})
+	}
+}
+
 func testAccPreCheck(t *testing.T) {
 	var keys []string
 	_, azure := os.LookupEnv("TF_ACC")
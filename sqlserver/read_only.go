@@ -0,0 +1,28 @@
+package sqlserver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// readOnly fails write operations of a read-only provider before a connector is
+// created.
+func readOnly(resourceType, operation string, f resourceFunc) resourceFunc {
+	return func(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		if !meta.(sqlserverProvider).options.ReadOnly {
+			return f(ctx, data, meta)
+		}
+
+		if operation == "update" {
+			data.Partial(true)
+		}
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("unable to %s %s: the provider is read-only", operation, resourceType),
+			Detail:   "The provider is configured with `read_only = true`, which only allows reading servers. Remove `read_only` to apply changes.",
+		}}
+	}
+}
@@ -0,0 +1,51 @@
+package sqlserver
+
+import (
+	"context"
+	"testing"
+
+	"terraform-provider-sqlserver/sqlserver/model"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestReadOnly(t *testing.T) {
+	tests := []struct {
+		name        string
+		readOnly    bool
+		wantCalled  bool
+		wantSummary string
+	}{
+		{
+			name:        "read-only",
+			readOnly:    true,
+			wantSummary: "unable to delete sqlserver_user: the provider is read-only",
+		},
+		{
+			name:       "read-write",
+			wantCalled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			deleteUser := func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+				called = true
+				return nil
+			}
+			meta := sqlserverProvider{options: model.ConnectionOptions{ReadOnly: tt.readOnly}}
+			data := schema.TestResourceDataRaw(t, resourceUser().Schema, map[string]interface{}{})
+
+			diags := readOnly("sqlserver_user", "delete", deleteUser)(context.Background(), data, meta)
+
+			if called != tt.wantCalled {
+				t.Fatalf("readOnly() called delete = %v, want %v", called, tt.wantCalled)
+			}
+			if tt.wantSummary != "" && (len(diags) != 1 || diags[0].Summary != tt.wantSummary) {
+				t.Fatalf("readOnly() = %v, want %q", diags, tt.wantSummary)
+			}
+		})
+	}
+}
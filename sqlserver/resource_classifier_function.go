@@ -8,7 +8,6 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"github.com/pkg/errors"
 )
 
 type ClassifierFunctionConnector interface {
@@ -24,7 +23,10 @@ func resourceClassifierFunction() *schema.Resource {
 		ReadContext:   resourceClassifierFunctionRead,
 		UpdateContext: resourceClassifierFunctionUpdate,
 		DeleteContext: resourceClassifierFunctionDelete,
+		CustomizeDiff: requireFeature(resourceGovernorFeature, nil),
 		Schema: map[string]*schema.Schema{
+			serverProp:    getServerSchema(),
+			executeAsProp: getExecuteAsSchema("Overrides the execute_as block of the provider for this resource."),
 			classifierFunctionNameProp: {
 				Type:        schema.TypeString,
 				Required:    true,
@@ -65,8 +67,8 @@ func resourceClassifierFunction() *schema.Resource {
 }
 
 func resourceClassifierFunctionCreate(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "classifier_function", "create")
-	logger.Debug().Msgf("Create classifier function %s", data.Get(classifierFunctionNameProp).(string))
+	ctx, logger := loggerFromMeta(ctx, meta, data, "classifier_function", "create")
+	logger.Debugf("Create classifier function %s", data.Get(classifierFunctionNameProp).(string))
 
 	connector, err := getClassifierFunctionConnector(meta, data)
 	if err != nil {
@@ -80,18 +82,18 @@ func resourceClassifierFunctionCreate(ctx context.Context, data *schema.Resource
 	}
 
 	if err = connector.CreateClassifierFunction(ctx, fn); err != nil {
-		return diag.FromErr(errors.Wrapf(err, "unable to create classifier function [%s].[%s]", fn.SchemaName, fn.Name))
+		return sqlDiagnostics(err, fmt.Sprintf("unable to create classifier function [%s].[%s]", fn.SchemaName, fn.Name), classifierFunctionErrorAttributes)
 	}
 
 	data.SetId(getClassifierFunctionID(meta, data))
-	logger.Info().Msgf("created classifier function [%s].[%s]", fn.SchemaName, fn.Name)
+	logger.Infof("created classifier function [%s].[%s]", fn.SchemaName, fn.Name)
 
 	return resourceClassifierFunctionRead(ctx, data, meta)
 }
 
 func resourceClassifierFunctionRead(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "classifier_function", "read")
-	logger.Debug().Msgf("Read classifier function %s", data.Id())
+	ctx, logger := loggerFromMeta(ctx, meta, data, "classifier_function", "read")
+	logger.Debugf("Read classifier function %s", data.Id())
 
 	schemaName := data.Get(schemaNameProp).(string)
 	name := data.Get(classifierFunctionNameProp).(string)
@@ -103,10 +105,10 @@ func resourceClassifierFunctionRead(ctx context.Context, data *schema.ResourceDa
 
 	fn, err := connector.GetClassifierFunction(ctx, schemaName, name)
 	if err != nil {
-		return diag.FromErr(errors.Wrapf(err, "unable to read classifier function [%s].[%s]", schemaName, name))
+		return sqlDiagnostics(err, fmt.Sprintf("unable to read classifier function [%s].[%s]", schemaName, name), classifierFunctionErrorAttributes)
 	}
 	if fn == nil {
-		logger.Info().Msgf("No classifier function found for [%s].[%s]", schemaName, name)
+		logger.Infof("No classifier function found for [%s].[%s]", schemaName, name)
 		data.SetId("")
 		return nil
 	}
@@ -121,8 +123,8 @@ func resourceClassifierFunctionRead(ctx context.Context, data *schema.ResourceDa
 }
 
 func resourceClassifierFunctionUpdate(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "classifier_function", "update")
-	logger.Debug().Msgf("Update classifier function %s", data.Id())
+	ctx, logger := loggerFromMeta(ctx, meta, data, "classifier_function", "update")
+	logger.Debugf("Update classifier function %s", data.Id())
 
 	connector, err := getClassifierFunctionConnector(meta, data)
 	if err != nil {
@@ -136,17 +138,17 @@ func resourceClassifierFunctionUpdate(ctx context.Context, data *schema.Resource
 	}
 
 	if err = connector.UpdateClassifierFunction(ctx, fn); err != nil {
-		return diag.FromErr(errors.Wrapf(err, "unable to update classifier function [%s].[%s]", fn.SchemaName, fn.Name))
+		return sqlDiagnostics(err, fmt.Sprintf("unable to update classifier function [%s].[%s]", fn.SchemaName, fn.Name), classifierFunctionErrorAttributes)
 	}
 
-	logger.Info().Msgf("updated classifier function [%s].[%s]", fn.SchemaName, fn.Name)
+	logger.Infof("updated classifier function [%s].[%s]", fn.SchemaName, fn.Name)
 
 	return resourceClassifierFunctionRead(ctx, data, meta)
 }
 
 func resourceClassifierFunctionDelete(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "classifier_function", "delete")
-	logger.Debug().Msgf("Delete classifier function %s", data.Id())
+	ctx, logger := loggerFromMeta(ctx, meta, data, "classifier_function", "delete")
+	logger.Debugf("Delete classifier function %s", data.Id())
 
 	schemaName := data.Get(schemaNameProp).(string)
 	name := data.Get(classifierFunctionNameProp).(string)
@@ -157,11 +159,11 @@ func resourceClassifierFunctionDelete(ctx context.Context, data *schema.Resource
 	}
 
 	if err = connector.DeleteClassifierFunction(ctx, schemaName, name); err != nil {
-		return diag.FromErr(errors.Wrapf(err, "unable to delete classifier function [%s].[%s]", schemaName, name))
+		return sqlDiagnostics(err, fmt.Sprintf("unable to delete classifier function [%s].[%s]", schemaName, name), classifierFunctionErrorAttributes)
 	}
 
 	data.SetId("")
-	logger.Info().Msgf("deleted classifier function [%s].[%s]", schemaName, name)
+	logger.Infof("deleted classifier function [%s].[%s]", schemaName, name)
 
 	return nil
 }
@@ -176,12 +178,9 @@ func getClassifierFunctionConnector(meta interface{}, data *schema.ResourceData)
 }
 
 func getClassifierFunctionID(meta interface{}, data *schema.ResourceData) string {
-	provider := meta.(sqlserverProvider)
-	host := provider.host
-	port := provider.port
 	schemaName := data.Get(schemaNameProp).(string)
 	name := data.Get(classifierFunctionNameProp).(string)
-	return fmt.Sprintf("sqlserver://%s:%s/classifier_function/%s.%s", host, port, schemaName, name)
+	return resourceID(meta, data, fmt.Sprintf("classifier_function/%s.%s", schemaName, name))
 }
 
 // Helper function to parse schema.name format
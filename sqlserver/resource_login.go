@@ -2,6 +2,7 @@ package sqlserver
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"terraform-provider-sqlserver/sqlserver/model"
 
@@ -33,10 +34,15 @@ func resourceLogin() *schema.Resource {
 		ReadContext:   resourceLoginRead,
 		UpdateContext: resourceLoginUpdate,
 		DeleteContext: resourceLoginDelete,
+		CustomizeDiff: requireFeature(externalLoginFeature, func(diff *schema.ResourceDiff) bool {
+			return len(diff.Get(LoginSourceTypeExternal).([]interface{})) > 0
+		}),
 		// Importer: &schema.ResourceImporter{
 		// 	StateContext: resourceLoginImport,
 		// },
 		Schema: map[string]*schema.Schema{
+			serverProp:    getServerSchema(),
+			executeAsProp: getExecuteAsSchema("Overrides the execute_as block of the provider for this resource."),
 			"sql_login": {
 				Type:         schema.TypeList,
 				MaxItems:     1,
@@ -101,15 +107,15 @@ func resourceLogin() *schema.Resource {
 }
 
 func resourceLoginCreate(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "login", "create")
-	logger.Debug().Msgf("Create %s", getLoginID(meta, data))
+	ctx, logger := loggerFromMeta(ctx, meta, data, "login", "create")
+	logger.Debugf("Create %s", getLoginID(meta, data))
 
 	// sid := data.Get(sidStrProp).(string)
 
-	logger.Debug().Msgf("timeoutRead %s", data.Timeout(schema.TimeoutRead))
-	logger.Debug().Msgf("timeoutCreate %s", data.Timeout(schema.TimeoutCreate))
-	logger.Debug().Msgf("timeoutUpdate %s", data.Timeout(schema.TimeoutUpdate))
-	logger.Debug().Msgf("timeoutDelete %s", data.Timeout(schema.TimeoutDelete))
+	logger.Debugf("timeoutRead %s", data.Timeout(schema.TimeoutRead))
+	logger.Debugf("timeoutCreate %s", data.Timeout(schema.TimeoutCreate))
+	logger.Debugf("timeoutUpdate %s", data.Timeout(schema.TimeoutUpdate))
+	logger.Debugf("timeoutDelete %s", data.Timeout(schema.TimeoutDelete))
 
 	connector, err := getLoginConnector(meta, data)
 	if err != nil {
@@ -121,13 +127,14 @@ func resourceLoginCreate(ctx context.Context, data *schema.ResourceData, meta in
 
 		loginName := sqlLogin[loginNameProp].(string)
 		password := sqlLogin[passwordProp].(string)
+		ctx = logger.mask(model.SqlLogin{Username: loginName, Password: password})
 
 		if err = connector.CreateLogin(ctx, loginName, password, "SQL"); err != nil {
-			logger.Debug().Msgf("Error: %s", err)
-			return diag.FromErr(errors.Wrapf(err, "unable to create login [%s]", loginName))
+			logger.Debugf("Error: %s", err)
+			return sqlDiagnostics(err, fmt.Sprintf("unable to create login [%s]", loginName), loginErrorAttributes(data))
 		}
 
-		logger.Info().Msgf("created SQL login [%s]", loginName)
+		logger.Infof("created SQL login [%s]", loginName)
 	} else if externalLogin, hasExternalLogin := data.GetOk(LoginSourceTypeExternal); hasExternalLogin {
 		externalLogin := externalLogin.([]interface{})[0].(map[string]interface{})
 
@@ -144,11 +151,11 @@ func resourceLoginCreate(ctx context.Context, data *schema.ResourceData, meta in
 		}
 
 		if err = connector.CreateLogin(ctx, loginName, "", sourceType); err != nil {
-			logger.Debug().Msgf("Error: %s", err)
-			return diag.FromErr(errors.Wrapf(err, "unable to create external login [%s]", loginName))
+			logger.Debugf("Error: %s", err)
+			return sqlDiagnostics(err, fmt.Sprintf("unable to create external login [%s]", loginName), loginErrorAttributes(data))
 		}
 
-		logger.Info().Msgf("created external login [%s]", loginName)
+		logger.Infof("created external login [%s]", loginName)
 	} else {
 		return diag.Errorf("either sql_login or external_login must be specified")
 	}
@@ -160,8 +167,8 @@ func resourceLoginCreate(ctx context.Context, data *schema.ResourceData, meta in
 }
 
 func resourceLoginRead(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "login", "read")
-	logger.Debug().Msgf("Read %s", getLoginID(meta, data))
+	ctx, logger := loggerFromMeta(ctx, meta, data, "login", "read")
+	logger.Debugf("Read %s", getLoginID(meta, data))
 
 	var loginName string
 	if sqlLogin, hasSqlLogin := data.GetOk(LoginSourceTypeSQL); hasSqlLogin {
@@ -181,10 +188,10 @@ func resourceLoginRead(ctx context.Context, data *schema.ResourceData, meta inte
 
 	login, err := connector.GetLogin(ctx, loginName)
 	if err != nil {
-		return diag.FromErr(errors.Wrapf(err, "unable to read login [%s]", loginName))
+		return sqlDiagnostics(err, fmt.Sprintf("unable to read login [%s]", loginName), loginErrorAttributes(data))
 	}
 	if login == nil {
-		logger.Info().Msgf("No login found for [%s]", loginName)
+		logger.Infof("No login found for [%s]", loginName)
 		data.SetId("")
 	} else {
 
@@ -200,8 +207,8 @@ func resourceLoginRead(ctx context.Context, data *schema.ResourceData, meta inte
 }
 
 func resourceLoginUpdate(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "login", "update")
-	logger.Debug().Msgf("Update %s", data.Id())
+	ctx, logger := loggerFromMeta(ctx, meta, data, "login", "update")
+	logger.Debugf("Update %s", data.Id())
 
 	connector, err := getLoginConnector(meta, data)
 	if err != nil {
@@ -213,12 +220,13 @@ func resourceLoginUpdate(ctx context.Context, data *schema.ResourceData, meta in
 
 		loginName := sqlLogin[loginNameProp].(string)
 		password := sqlLogin[passwordProp].(string)
+		ctx = logger.mask(model.SqlLogin{Username: loginName, Password: password})
 
 		if err = connector.UpdateLogin(ctx, loginName, password); err != nil {
-			return diag.FromErr(errors.Wrapf(err, "unable to update login [%s]", loginName))
+			return sqlDiagnostics(err, fmt.Sprintf("unable to update login [%s]", loginName), loginErrorAttributes(data))
 		}
 
-		logger.Info().Msgf("updated SQL login [%s]", loginName)
+		logger.Infof("updated SQL login [%s]", loginName)
 	} else if _, hasExternalLogin := data.GetOk(LoginSourceTypeExternal); hasExternalLogin {
 		panic("external login update is not supported")
 	} else {
@@ -229,8 +237,8 @@ func resourceLoginUpdate(ctx context.Context, data *schema.ResourceData, meta in
 }
 
 func resourceLoginDelete(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "login", "delete")
-	logger.Debug().Msgf("Delete %s", data.Id())
+	ctx, logger := loggerFromMeta(ctx, meta, data, "login", "delete")
+	logger.Debugf("Delete %s", data.Id())
 
 	var loginName string
 	if sqlLogin, hasSqlLogin := data.GetOk(LoginSourceTypeSQL); hasSqlLogin {
@@ -249,10 +257,10 @@ func resourceLoginDelete(ctx context.Context, data *schema.ResourceData, meta in
 	}
 
 	if err = connector.DeleteLogin(ctx, loginName); err != nil {
-		return diag.FromErr(errors.Wrapf(err, "unable to delete login [%s]", loginName))
+		return sqlDiagnostics(err, fmt.Sprintf("unable to delete login [%s]", loginName), loginErrorAttributes(data))
 	}
 
-	logger.Info().Msgf("deleted login [%s]", loginName)
+	logger.Infof("deleted login [%s]", loginName)
 
 	// d.SetId("") is automatically called assuming delete returns no errors, but it is added here for explicitness.
 	data.SetId("")
@@ -261,8 +269,8 @@ func resourceLoginDelete(ctx context.Context, data *schema.ResourceData, meta in
 }
 
 func resourceLoginImport(ctx context.Context, data *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-	logger := loggerFromMeta(meta, "login", "import")
-	logger.Debug().Msgf("Import %s", data.Id())
+	ctx, logger := loggerFromMeta(ctx, meta, data, "login", "import")
+	logger.Debugf("Import %s", data.Id())
 
 	id := data.Id()
 	_, u, err := serverFromId(id)
@@ -2,12 +2,10 @@ package sqlserver
 
 import (
 	"context"
-	"fmt"
 	"terraform-provider-sqlserver/sqlserver/model"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"github.com/pkg/errors"
 )
 
 type ResourceGovernorConnector interface {
@@ -23,7 +21,10 @@ func resourceResourceGovernor() *schema.Resource {
 		ReadContext:   resourceResourceGovernorRead,
 		UpdateContext: resourceResourceGovernorUpdate,
 		DeleteContext: resourceResourceGovernorDelete,
+		CustomizeDiff: requireFeature(resourceGovernorFeature, nil),
 		Schema: map[string]*schema.Schema{
+			serverProp:    getServerSchema(),
+			executeAsProp: getExecuteAsSchema("Overrides the execute_as block of the provider for this resource."),
 			enabledProp: {
 				Type:        schema.TypeBool,
 				Optional:    true,
@@ -48,8 +49,8 @@ func resourceResourceGovernor() *schema.Resource {
 }
 
 func resourceResourceGovernorCreate(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "resource_governor", "create")
-	logger.Debug().Msg("Create/configure resource governor")
+	ctx, logger := loggerFromMeta(ctx, meta, data, "resource_governor", "create")
+	logger.Debugf("Create/configure resource governor")
 
 	connector, err := getResourceGovernorConnector(meta, data)
 	if err != nil {
@@ -61,23 +62,23 @@ func resourceResourceGovernorCreate(ctx context.Context, data *schema.ResourceDa
 
 	if enabled {
 		if err = connector.EnableResourceGovernor(ctx, classifierFunction); err != nil {
-			return diag.FromErr(errors.Wrap(err, "unable to enable resource governor"))
+			return sqlDiagnostics(err, "unable to enable resource governor", nil)
 		}
 	} else {
 		if err = connector.DisableResourceGovernor(ctx); err != nil {
-			return diag.FromErr(errors.Wrap(err, "unable to disable resource governor"))
+			return sqlDiagnostics(err, "unable to disable resource governor", nil)
 		}
 	}
 
-	data.SetId(getResourceGovernorID(meta))
-	logger.Info().Msg("configured resource governor")
+	data.SetId(getResourceGovernorID(meta, data))
+	logger.Infof("configured resource governor")
 
 	return resourceResourceGovernorRead(ctx, data, meta)
 }
 
 func resourceResourceGovernorRead(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "resource_governor", "read")
-	logger.Debug().Msgf("Read resource governor %s", data.Id())
+	ctx, logger := loggerFromMeta(ctx, meta, data, "resource_governor", "read")
+	logger.Debugf("Read resource governor %s", data.Id())
 
 	connector, err := getResourceGovernorConnector(meta, data)
 	if err != nil {
@@ -86,7 +87,7 @@ func resourceResourceGovernorRead(ctx context.Context, data *schema.ResourceData
 
 	rg, err := connector.GetResourceGovernor(ctx)
 	if err != nil {
-		return diag.FromErr(errors.Wrap(err, "unable to read resource governor configuration"))
+		return sqlDiagnostics(err, "unable to read resource governor configuration", nil)
 	}
 
 	data.Set(enabledProp, rg.IsEnabled)
@@ -96,8 +97,8 @@ func resourceResourceGovernorRead(ctx context.Context, data *schema.ResourceData
 }
 
 func resourceResourceGovernorUpdate(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "resource_governor", "update")
-	logger.Debug().Msgf("Update resource governor %s", data.Id())
+	ctx, logger := loggerFromMeta(ctx, meta, data, "resource_governor", "update")
+	logger.Debugf("Update resource governor %s", data.Id())
 
 	connector, err := getResourceGovernorConnector(meta, data)
 	if err != nil {
@@ -110,17 +111,17 @@ func resourceResourceGovernorUpdate(ctx context.Context, data *schema.ResourceDa
 	}
 
 	if err = connector.UpdateResourceGovernor(ctx, rg); err != nil {
-		return diag.FromErr(errors.Wrap(err, "unable to update resource governor"))
+		return sqlDiagnostics(err, "unable to update resource governor", nil)
 	}
 
-	logger.Info().Msg("updated resource governor")
+	logger.Infof("updated resource governor")
 
 	return resourceResourceGovernorRead(ctx, data, meta)
 }
 
 func resourceResourceGovernorDelete(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "resource_governor", "delete")
-	logger.Debug().Msgf("Delete resource governor configuration %s", data.Id())
+	ctx, logger := loggerFromMeta(ctx, meta, data, "resource_governor", "delete")
+	logger.Debugf("Delete resource governor configuration %s", data.Id())
 
 	connector, err := getResourceGovernorConnector(meta, data)
 	if err != nil {
@@ -129,11 +130,11 @@ func resourceResourceGovernorDelete(ctx context.Context, data *schema.ResourceDa
 
 	// Disable resource governor on delete
 	if err = connector.DisableResourceGovernor(ctx); err != nil {
-		return diag.FromErr(errors.Wrap(err, "unable to disable resource governor"))
+		return sqlDiagnostics(err, "unable to disable resource governor", nil)
 	}
 
 	data.SetId("")
-	logger.Info().Msg("disabled resource governor")
+	logger.Infof("disabled resource governor")
 
 	return nil
 }
@@ -147,9 +148,6 @@ func getResourceGovernorConnector(meta interface{}, data *schema.ResourceData) (
 	return connector.(ResourceGovernorConnector), nil
 }
 
-func getResourceGovernorID(meta interface{}) string {
-	provider := meta.(sqlserverProvider)
-	host := provider.host
-	port := provider.port
-	return fmt.Sprintf("sqlserver://%s:%s/resource_governor", host, port)
+func getResourceGovernorID(meta interface{}, data *schema.ResourceData) string {
+	return resourceID(meta, data, "resource_governor")
 }
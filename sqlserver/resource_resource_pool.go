@@ -8,7 +8,6 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
-	"github.com/pkg/errors"
 )
 
 type ResourcePoolConnector interface {
@@ -24,7 +23,10 @@ func resourceResourcePool() *schema.Resource {
 		ReadContext:   resourceResourcePoolRead,
 		UpdateContext: resourceResourcePoolUpdate,
 		DeleteContext: resourceResourcePoolDelete,
+		CustomizeDiff: requireFeature(resourceGovernorFeature, nil),
 		Schema: map[string]*schema.Schema{
+			serverProp:    getServerSchema(),
+			executeAsProp: getExecuteAsSchema("Overrides the execute_as block of the provider for this resource."),
 			resourcePoolNameProp: {
 				Type:        schema.TypeString,
 				Required:    true,
@@ -97,8 +99,8 @@ func resourceResourcePool() *schema.Resource {
 }
 
 func resourceResourcePoolCreate(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "resource_pool", "create")
-	logger.Debug().Msgf("Create resource pool %s", data.Get(resourcePoolNameProp).(string))
+	ctx, logger := loggerFromMeta(ctx, meta, data, "resource_pool", "create")
+	logger.Debugf("Create resource pool %s", data.Get(resourcePoolNameProp).(string))
 
 	connector, err := getResourcePoolConnector(meta, data)
 	if err != nil {
@@ -117,18 +119,18 @@ func resourceResourcePoolCreate(ctx context.Context, data *schema.ResourceData,
 	}
 
 	if err = connector.CreateResourcePool(ctx, pool); err != nil {
-		return diag.FromErr(errors.Wrapf(err, "unable to create resource pool [%s]", pool.Name))
+		return sqlDiagnostics(err, fmt.Sprintf("unable to create resource pool [%s]", pool.Name), nil)
 	}
 
 	data.SetId(getResourcePoolID(meta, data))
-	logger.Info().Msgf("created resource pool [%s]", pool.Name)
+	logger.Infof("created resource pool [%s]", pool.Name)
 
 	return resourceResourcePoolRead(ctx, data, meta)
 }
 
 func resourceResourcePoolRead(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "resource_pool", "read")
-	logger.Debug().Msgf("Read resource pool %s", data.Id())
+	ctx, logger := loggerFromMeta(ctx, meta, data, "resource_pool", "read")
+	logger.Debugf("Read resource pool %s", data.Id())
 
 	name := data.Get(resourcePoolNameProp).(string)
 
@@ -139,10 +141,10 @@ func resourceResourcePoolRead(ctx context.Context, data *schema.ResourceData, me
 
 	pool, err := connector.GetResourcePool(ctx, name)
 	if err != nil {
-		return diag.FromErr(errors.Wrapf(err, "unable to read resource pool [%s]", name))
+		return sqlDiagnostics(err, fmt.Sprintf("unable to read resource pool [%s]", name), nil)
 	}
 	if pool == nil {
-		logger.Info().Msgf("No resource pool found for [%s]", name)
+		logger.Infof("No resource pool found for [%s]", name)
 		data.SetId("")
 		return nil
 	}
@@ -161,8 +163,8 @@ func resourceResourcePoolRead(ctx context.Context, data *schema.ResourceData, me
 }
 
 func resourceResourcePoolUpdate(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "resource_pool", "update")
-	logger.Debug().Msgf("Update resource pool %s", data.Id())
+	ctx, logger := loggerFromMeta(ctx, meta, data, "resource_pool", "update")
+	logger.Debugf("Update resource pool %s", data.Id())
 
 	connector, err := getResourcePoolConnector(meta, data)
 	if err != nil {
@@ -181,17 +183,17 @@ func resourceResourcePoolUpdate(ctx context.Context, data *schema.ResourceData,
 	}
 
 	if err = connector.UpdateResourcePool(ctx, pool); err != nil {
-		return diag.FromErr(errors.Wrapf(err, "unable to update resource pool [%s]", pool.Name))
+		return sqlDiagnostics(err, fmt.Sprintf("unable to update resource pool [%s]", pool.Name), nil)
 	}
 
-	logger.Info().Msgf("updated resource pool [%s]", pool.Name)
+	logger.Infof("updated resource pool [%s]", pool.Name)
 
 	return resourceResourcePoolRead(ctx, data, meta)
 }
 
 func resourceResourcePoolDelete(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "resource_pool", "delete")
-	logger.Debug().Msgf("Delete resource pool %s", data.Id())
+	ctx, logger := loggerFromMeta(ctx, meta, data, "resource_pool", "delete")
+	logger.Debugf("Delete resource pool %s", data.Id())
 
 	name := data.Get(resourcePoolNameProp).(string)
 
@@ -201,11 +203,11 @@ func resourceResourcePoolDelete(ctx context.Context, data *schema.ResourceData,
 	}
 
 	if err = connector.DeleteResourcePool(ctx, name); err != nil {
-		return diag.FromErr(errors.Wrapf(err, "unable to delete resource pool [%s]", name))
+		return sqlDiagnostics(err, fmt.Sprintf("unable to delete resource pool [%s]", name), nil)
 	}
 
 	data.SetId("")
-	logger.Info().Msgf("deleted resource pool [%s]", name)
+	logger.Infof("deleted resource pool [%s]", name)
 
 	return nil
 }
@@ -220,9 +222,6 @@ func getResourcePoolConnector(meta interface{}, data *schema.ResourceData) (Reso
 }
 
 func getResourcePoolID(meta interface{}, data *schema.ResourceData) string {
-	provider := meta.(sqlserverProvider)
-	host := provider.host
-	port := provider.port
 	name := data.Get(resourcePoolNameProp).(string)
-	return fmt.Sprintf("sqlserver://%s:%s/resource_pool/%s", host, port, name)
+	return resourceID(meta, data, "resource_pool/"+name)
 }
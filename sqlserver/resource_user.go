@@ -2,6 +2,7 @@ package sqlserver
 
 import (
 	"context"
+	"fmt"
 
 	"terraform-provider-sqlserver/sqlserver/model"
 
@@ -26,6 +27,8 @@ func resourceUser() *schema.Resource {
 		// 	StateContext: resourceUserImport,
 		// },
 		Schema: map[string]*schema.Schema{
+			serverProp:    getServerSchema(),
+			executeAsProp: getExecuteAsSchema("Overrides the execute_as block of the provider for this resource."),
 			databaseProp: {
 				Type:     schema.TypeString,
 				Optional: true,
@@ -148,8 +151,8 @@ func getUsernameFromData(data *schema.ResourceData) (string, string, error) {
 }
 
 func resourceUserCreate(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "user", "create")
-	logger.Debug().Msgf("Create %s", getUserID(meta, data))
+	ctx, logger := loggerFromMeta(ctx, meta, data, "user", "create")
+	logger.Debugf("Create %s", getUserID(meta, data))
 
 	database := data.Get(databaseProp).(string)
 	roles := data.Get(rolesProp).(*schema.Set).List()
@@ -173,7 +176,7 @@ func resourceUserCreate(ctx context.Context, data *schema.ResourceData, meta int
 			Roles:     toStringSlice(roles),
 		}
 
-		logger.Info().Msgf("creating instance user [%s].[%s] for login [%s]", database, username, loginName)
+		logger.Infof("creating instance user [%s].[%s] for login [%s]", database, username, loginName)
 	} else if databaseUser, hasDatabaseUser := data.GetOk(UserSourceTypeDatabase); hasDatabaseUser {
 		userData := databaseUser.([]interface{})[0].(map[string]interface{})
 		username := userData[usernameProp].(string)
@@ -185,8 +188,9 @@ func resourceUserCreate(ctx context.Context, data *schema.ResourceData, meta int
 			AuthType: "DATABASE",
 			Roles:    toStringSlice(roles),
 		}
+		ctx = logger.mask(user)
 
-		logger.Info().Msgf("creating database user [%s].[%s]", database, username)
+		logger.Infof("creating database user [%s].[%s]", database, username)
 	} else if externalUser, hasExternalUser := data.GetOk(UserSourceTypeExternal); hasExternalUser {
 		userData := externalUser.([]interface{})[0].(map[string]interface{})
 		username := userData[usernameProp].(string)
@@ -202,25 +206,25 @@ func resourceUserCreate(ctx context.Context, data *schema.ResourceData, meta int
 			Roles:    toStringSlice(roles),
 		}
 
-		logger.Info().Msgf("creating external user [%s].[%s]", database, username)
+		logger.Infof("creating external user [%s].[%s]", database, username)
 	} else {
 		return diag.Errorf("one of instance_user, database_user, or external_user must be specified")
 	}
 
 	if err = connector.CreateUser(ctx, database, user); err != nil {
-		return diag.FromErr(errors.Wrapf(err, "unable to create user [%s].[%s]", database, user.Username))
+		return sqlDiagnostics(err, fmt.Sprintf("unable to create user [%s].[%s]", database, user.Username), userErrorAttributes(data))
 	}
 
 	data.SetId(getUserID(meta, data))
 
-	logger.Info().Msgf("created user [%s].[%s]", database, user.Username)
+	logger.Infof("created user [%s].[%s]", database, user.Username)
 
 	return resourceUserRead(ctx, data, meta)
 }
 
 func resourceUserRead(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "user", "read")
-	logger.Debug().Msgf("Read %s", data.Id())
+	ctx, logger := loggerFromMeta(ctx, meta, data, "user", "read")
+	logger.Debugf("Read %s", data.Id())
 
 	database := data.Get(databaseProp).(string)
 	username, _, err := getUsernameFromData(data)
@@ -235,10 +239,10 @@ func resourceUserRead(ctx context.Context, data *schema.ResourceData, meta inter
 
 	user, err := connector.GetUser(ctx, database, username)
 	if err != nil {
-		return diag.FromErr(errors.Wrapf(err, "unable to read user [%s].[%s]", database, username))
+		return sqlDiagnostics(err, fmt.Sprintf("unable to read user [%s].[%s]", database, username), userErrorAttributes(data))
 	}
 	if user == nil {
-		logger.Info().Msgf("No user found for [%s].[%s]", database, username)
+		logger.Infof("No user found for [%s].[%s]", database, username)
 		data.SetId("")
 	} else {
 		if err = data.Set(sidStrProp, user.SIDStr); err != nil {
@@ -259,8 +263,8 @@ func resourceUserRead(ctx context.Context, data *schema.ResourceData, meta inter
 }
 
 func resourceUserUpdate(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "user", "update")
-	logger.Debug().Msgf("Update %s", data.Id())
+	ctx, logger := loggerFromMeta(ctx, meta, data, "user", "update")
+	logger.Debugf("Update %s", data.Id())
 
 	database := data.Get(databaseProp).(string)
 	username, _, err := getUsernameFromData(data)
@@ -279,19 +283,19 @@ func resourceUserUpdate(ctx context.Context, data *schema.ResourceData, meta int
 		Roles:    toStringSlice(roles),
 	}
 	if err = connector.UpdateUser(ctx, database, user); err != nil {
-		return diag.FromErr(errors.Wrapf(err, "unable to update user [%s].[%s]", database, username))
+		return sqlDiagnostics(err, fmt.Sprintf("unable to update user [%s].[%s]", database, username), userErrorAttributes(data))
 	}
 
 	data.SetId(getUserID(meta, data))
 
-	logger.Info().Msgf("updated user [%s].[%s]", database, username)
+	logger.Infof("updated user [%s].[%s]", database, username)
 
 	return resourceUserRead(ctx, data, meta)
 }
 
 func resourceUserDelete(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "user", "delete")
-	logger.Debug().Msgf("Delete %s", data.Id())
+	ctx, logger := loggerFromMeta(ctx, meta, data, "user", "delete")
+	logger.Debugf("Delete %s", data.Id())
 
 	database := data.Get(databaseProp).(string)
 	username, _, err := getUsernameFromData(data)
@@ -305,10 +309,10 @@ func resourceUserDelete(ctx context.Context, data *schema.ResourceData, meta int
 	}
 
 	if err = connector.DeleteUser(ctx, database, username); err != nil {
-		return diag.FromErr(errors.Wrapf(err, "unable to delete user [%s].[%s]", database, username))
+		return sqlDiagnostics(err, fmt.Sprintf("unable to delete user [%s].[%s]", database, username), userErrorAttributes(data))
 	}
 
-	logger.Info().Msgf("deleted user [%s].[%s]", database, username)
+	logger.Infof("deleted user [%s].[%s]", database, username)
 
 	// d.SetId("") is automatically called assuming delete returns no errors, but it is added here for explicitness.
 	data.SetId("")
@@ -317,8 +321,8 @@ func resourceUserDelete(ctx context.Context, data *schema.ResourceData, meta int
 }
 
 // func resourceUserImport(ctx context.Context, data *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-// 	logger := loggerFromMeta(meta, "user", "import")
-// 	logger.Debug().Msgf("Import %s", data.Id())
+// 	ctx, logger := loggerFromMeta(ctx, meta, data, "user", "import")
+// 	logger.Debugf("Import %s", data.Id())
 
 // 	server, u, err := serverFromId(data.Id())
 // 	if err != nil {
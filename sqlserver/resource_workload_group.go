@@ -9,7 +9,6 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
-	"github.com/pkg/errors"
 )
 
 type WorkloadGroupConnector interface {
@@ -27,7 +26,10 @@ func resourceWorkloadGroup() *schema.Resource {
 		ReadContext:   resourceWorkloadGroupRead,
 		UpdateContext: resourceWorkloadGroupUpdate,
 		DeleteContext: resourceWorkloadGroupDelete,
+		CustomizeDiff: requireFeature(resourceGovernorFeature, nil),
 		Schema: map[string]*schema.Schema{
+			serverProp:    getServerSchema(),
+			executeAsProp: getExecuteAsSchema("Overrides the execute_as block of the provider for this resource."),
 			workloadGroupNameProp: {
 				Type:        schema.TypeString,
 				Required:    true,
@@ -98,8 +100,8 @@ func resourceWorkloadGroup() *schema.Resource {
 }
 
 func resourceWorkloadGroupCreate(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "workload_group", "create")
-	logger.Debug().Msgf("Create workload group %s", data.Get(workloadGroupNameProp).(string))
+	ctx, logger := loggerFromMeta(ctx, meta, data, "workload_group", "create")
+	logger.Debugf("Create workload group %s", data.Get(workloadGroupNameProp).(string))
 
 	connector, err := getWorkloadGroupConnector(meta, data)
 	if err != nil {
@@ -118,18 +120,18 @@ func resourceWorkloadGroupCreate(ctx context.Context, data *schema.ResourceData,
 	}
 
 	if err = connector.CreateWorkloadGroup(ctx, group); err != nil {
-		return diag.FromErr(errors.Wrapf(err, "unable to create workload group [%s]", group.Name))
+		return sqlDiagnostics(err, fmt.Sprintf("unable to create workload group [%s]", group.Name), nil)
 	}
 
 	data.SetId(getWorkloadGroupID(meta, data))
-	logger.Info().Msgf("created workload group [%s]", group.Name)
+	logger.Infof("created workload group [%s]", group.Name)
 
 	return resourceWorkloadGroupRead(ctx, data, meta)
 }
 
 func resourceWorkloadGroupRead(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "workload_group", "read")
-	logger.Debug().Msgf("Read workload group %s", data.Id())
+	ctx, logger := loggerFromMeta(ctx, meta, data, "workload_group", "read")
+	logger.Debugf("Read workload group %s", data.Id())
 
 	name := data.Get(workloadGroupNameProp).(string)
 
@@ -140,10 +142,10 @@ func resourceWorkloadGroupRead(ctx context.Context, data *schema.ResourceData, m
 
 	group, err := connector.GetWorkloadGroup(ctx, name)
 	if err != nil {
-		return diag.FromErr(errors.Wrapf(err, "unable to read workload group [%s]", name))
+		return sqlDiagnostics(err, fmt.Sprintf("unable to read workload group [%s]", name), nil)
 	}
 	if group == nil {
-		logger.Info().Msgf("No workload group found for [%s]", name)
+		logger.Infof("No workload group found for [%s]", name)
 		data.SetId("")
 		return nil
 	}
@@ -162,8 +164,8 @@ func resourceWorkloadGroupRead(ctx context.Context, data *schema.ResourceData, m
 }
 
 func resourceWorkloadGroupUpdate(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "workload_group", "update")
-	logger.Debug().Msgf("Update workload group %s", data.Id())
+	ctx, logger := loggerFromMeta(ctx, meta, data, "workload_group", "update")
+	logger.Debugf("Update workload group %s", data.Id())
 
 	connector, err := getWorkloadGroupConnector(meta, data)
 	if err != nil {
@@ -182,17 +184,17 @@ func resourceWorkloadGroupUpdate(ctx context.Context, data *schema.ResourceData,
 	}
 
 	if err = connector.UpdateWorkloadGroup(ctx, group); err != nil {
-		return diag.FromErr(errors.Wrapf(err, "unable to update workload group [%s]", group.Name))
+		return sqlDiagnostics(err, fmt.Sprintf("unable to update workload group [%s]", group.Name), nil)
 	}
 
-	logger.Info().Msgf("updated workload group [%s]", group.Name)
+	logger.Infof("updated workload group [%s]", group.Name)
 
 	return resourceWorkloadGroupRead(ctx, data, meta)
 }
 
 func resourceWorkloadGroupDelete(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	logger := loggerFromMeta(meta, "workload_group", "delete")
-	logger.Debug().Msgf("Delete workload group %s", data.Id())
+	ctx, logger := loggerFromMeta(ctx, meta, data, "workload_group", "delete")
+	logger.Debugf("Delete workload group %s", data.Id())
 
 	name := data.Get(workloadGroupNameProp).(string)
 
@@ -202,11 +204,11 @@ func resourceWorkloadGroupDelete(ctx context.Context, data *schema.ResourceData,
 	}
 
 	if err = connector.DeleteWorkloadGroup(ctx, name); err != nil {
-		return diag.FromErr(errors.Wrapf(err, "unable to delete workload group [%s]", name))
+		return sqlDiagnostics(err, fmt.Sprintf("unable to delete workload group [%s]", name), nil)
 	}
 
 	data.SetId("")
-	logger.Info().Msgf("deleted workload group [%s]", name)
+	logger.Infof("deleted workload group [%s]", name)
 
 	return nil
 }
@@ -221,9 +223,6 @@ func getWorkloadGroupConnector(meta interface{}, data *schema.ResourceData) (Wor
 }
 
 func getWorkloadGroupID(meta interface{}, data *schema.ResourceData) string {
-	provider := meta.(sqlserverProvider)
-	host := provider.host
-	port := provider.port
 	name := data.Get(workloadGroupNameProp).(string)
-	return fmt.Sprintf("sqlserver://%s:%s/workload_group/%s", host, port, name)
+	return resourceID(meta, data, "workload_group/"+name)
 }
@@ -6,11 +6,331 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"terraform-provider-sqlserver/sqlserver/model"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 const DefaultPort = "1433"
 
+// getServerSchema returns the schema of the server block that lets a resource
+// target another server than the one configured on the provider.
+func getServerSchema() *schema.Schema {
+	serverSchema := map[string]*schema.Schema{
+		"host": {
+			Type:        schema.TypeString,
+			Required:    true,
+			ForceNew:    true,
+			Description: "The hostname or IP address of the SQL Server.",
+			DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+				return strings.EqualFold(old, new)
+			},
+		},
+		"port": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+			Description: "The port of the SQL Server. Defaults to 1433, or to the port of `instance` resolved by the SQL Server Browser.",
+			DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+				instance := d.Get(serverProp + ".0.instance").(string)
+				return defaultPort(old, instance) == defaultPort(new, instance)
+			},
+		},
+		"instance": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+			Description: "The name of a named instance of the SQL Server.",
+			DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+				return strings.EqualFold(old, new)
+			},
+		},
+	}
+	for name, loginSchema := range getLoginSchemas(serverProp+".0.", false) {
+		serverSchema[name] = loginSchema
+	}
+
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		MaxItems:    1,
+		Optional:    true,
+		Description: "Overrides the host, port, instance and login of the provider for this resource.",
+		Elem: &schema.Resource{
+			Schema: serverSchema,
+		},
+	}
+}
+
+// defaultPort returns the port to connect to. Named instances without a port are
+// resolved by the SQL Server Browser, so their port stays empty.
+func defaultPort(port, instance string) string {
+	if port == "" && instance == "" {
+		return DefaultPort
+	}
+	return port
+}
+
+// resourceID returns the ID of a resource at path on the server of the resource.
+// The instance of named instances is added as a query parameter.
+func resourceID(meta interface{}, data *schema.ResourceData, path string) string {
+	host, port, instance, _ := meta.(sqlserverProvider).server(data)
+
+	id := "sqlserver://" + host
+	if port != "" {
+		id = "sqlserver://" + net.JoinHostPort(host, port)
+	}
+	id += "/" + path
+	if instance != "" {
+		id += "?" + url.Values{"instance": {instance}}.Encode()
+	}
+	return id
+}
+
+// getLoginSchemas returns the schemas of the login methods. The prefix is the
+// path of the block containing the login methods and is used for ConflictsWith.
+// Only the provider reads the logins from the environment with envDefaults, as
+// the server blocks of resources are stored in their state.
+func getLoginSchemas(prefix string, envDefaults bool) map[string]*schema.Schema {
+	envDefault := func(name string) schema.SchemaDefaultFunc {
+		if !envDefaults {
+			return nil
+		}
+		return schema.EnvDefaultFunc(name, nil)
+	}
+
+	conflictsWith := func(method string) []string {
+		conflicts := make([]string, 0, len(LoginMethods)-1)
+		for _, m := range LoginMethods {
+			if m != method {
+				conflicts = append(conflicts, prefix+m)
+			}
+		}
+		return conflicts
+	}
+
+	return map[string]*schema.Schema{
+		"login": {
+			Type:          schema.TypeList,
+			MaxItems:      1,
+			Optional:      true,
+			ConflictsWith: conflictsWith("login"),
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"username": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						DefaultFunc: envDefault("TF_SQLSERVER_USERNAME"),
+					},
+					"password": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						Sensitive:   true,
+						DefaultFunc: envDefault("TF_SQLSERVER_PASSWORD"),
+					},
+				},
+			},
+		},
+		"azure_login": {
+			Type:          schema.TypeList,
+			MaxItems:      1,
+			Optional:      true,
+			ConflictsWith: conflictsWith("azure_login"),
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"tenant_id": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						DefaultFunc: envDefault("TF_SQLSERVER_TENANT_ID"),
+					},
+					"client_id": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						DefaultFunc: envDefault("TF_SQLSERVER_CLIENT_ID"),
+					},
+					"client_secret": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						Sensitive:   true,
+						DefaultFunc: envDefault("TF_SQLSERVER_CLIENT_SECRET"),
+					},
+					"client_certificate_path": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Path to a PEM or PFX file with the client certificate and private key of the service principal.",
+						DefaultFunc: envDefault("TF_SQLSERVER_CLIENT_CERTIFICATE_PATH"),
+					},
+					"client_certificate": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						Sensitive:   true,
+						Description: "The client certificate and private key of the service principal as PEM text or base64 encoded PFX.",
+						DefaultFunc: envDefault("TF_SQLSERVER_CLIENT_CERTIFICATE"),
+					},
+					"client_certificate_password": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						Sensitive:   true,
+						Description: "The password of the PFX client certificate.",
+						DefaultFunc: envDefault("TF_SQLSERVER_CLIENT_CERTIFICATE_PASSWORD"),
+					},
+				},
+			},
+		},
+		"azuread_default_chain_auth": {
+			Type:          schema.TypeList,
+			MaxItems:      1,
+			Optional:      true,
+			ConflictsWith: conflictsWith("azuread_default_chain_auth"),
+			Elem:          &schema.Resource{},
+		},
+		"azuread_managed_identity_auth": {
+			Type:          schema.TypeList,
+			MaxItems:      1,
+			Optional:      true,
+			ConflictsWith: conflictsWith("azuread_managed_identity_auth"),
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"user_id": {
+						Type:     schema.TypeString,
+						Optional: true,
+					},
+				},
+			},
+		},
+		"access_token": {
+			Type:          schema.TypeString,
+			Optional:      true,
+			Sensitive:     true,
+			ConflictsWith: conflictsWith("access_token"),
+			Description:   "A database access token, e.g. obtained by `az account get-access-token --resource https://database.windows.net/`.",
+			DefaultFunc:   envDefault("TF_SQLSERVER_ACCESS_TOKEN"),
+		},
+		"access_token_command": {
+			Type:          schema.TypeList,
+			Optional:      true,
+			MinItems:      1,
+			ConflictsWith: conflictsWith("access_token_command"),
+			Description:   "A command and its arguments that writes a JSON object with `access_token` and `expires_on` to stdout.",
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+		},
+		"azuread_workload_identity_auth": {
+			Type:          schema.TypeList,
+			MaxItems:      1,
+			Optional:      true,
+			ConflictsWith: conflictsWith("azuread_workload_identity_auth"),
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"tenant_id": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						DefaultFunc: envDefault("AZURE_TENANT_ID"),
+					},
+					"client_id": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						DefaultFunc: envDefault("AZURE_CLIENT_ID"),
+					},
+					"token_file_path": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Path to the file containing the federated OIDC token.",
+						DefaultFunc: envDefault("AZURE_FEDERATED_TOKEN_FILE"),
+					},
+					"authority_host": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "The Azure AD authority host, e.g. `https://login.microsoftonline.com/`.",
+						DefaultFunc: envDefault("AZURE_AUTHORITY_HOST"),
+					},
+				},
+			},
+		},
+	}
+}
+
+// serverData reads the server block of a resource from its data or, when
+// planning, its diff.
+type serverData interface {
+	Get(key string) interface{}
+	GetOk(key string) (interface{}, bool)
+}
+
+// loginFromData reads the login method configured in the block at prefix. It
+// returns nil if no login method is configured.
+func loginFromData(data serverData, prefix string) interface{} {
+	var login interface{}
+	// access_token comes first, as it may be set from the environment and the
+	// login blocks take precedence
+	if token, ok := data.GetOk(prefix + "access_token"); ok {
+		login = model.AccessToken{
+			Token: token.(string),
+		}
+	}
+
+	if command, ok := data.GetOk(prefix + "access_token_command"); ok {
+		args := make([]string, 0, len(command.([]interface{})))
+		for _, arg := range command.([]interface{}) {
+			args = append(args, arg.(string))
+		}
+		login = model.AccessToken{
+			Command: args,
+		}
+	}
+
+	if admin, ok := data.GetOk(prefix + "login"); ok {
+		admin := admin.([]interface{})
+		adminMap := admin[0].(map[string]interface{})
+		login = model.SqlLogin{
+			Username: adminMap["username"].(string),
+			Password: adminMap["password"].(string),
+		}
+	}
+
+	if admin, ok := data.GetOk(prefix + "azure_login"); ok {
+		admin := admin.([]interface{})
+		adminMap := admin[0].(map[string]interface{})
+		login = model.AzureLogin{
+			TenantID:                  adminMap["tenant_id"].(string),
+			ClientID:                  adminMap["client_id"].(string),
+			ClientSecret:              adminMap["client_secret"].(string),
+			ClientCertificatePath:     adminMap["client_certificate_path"].(string),
+			ClientCertificate:         adminMap["client_certificate"].(string),
+			ClientCertificatePassword: adminMap["client_certificate_password"].(string),
+		}
+	}
+
+	if admin := data.Get(prefix + "azuread_default_chain_auth").([]interface{}); len(admin) > 0 {
+		login = model.FedauthDefault{}
+	}
+
+	if admin, ok := data.GetOk(prefix + "azuread_managed_identity_auth"); ok {
+		admin := admin.([]interface{})
+		login = model.FedauthMSI{}
+		if adminMap, ok := admin[0].(map[string]interface{}); ok {
+			login = model.FedauthMSI{
+				UserID: adminMap["user_id"].(string),
+			}
+		}
+	}
+
+	if admin, ok := data.GetOk(prefix + "azuread_workload_identity_auth"); ok {
+		admin := admin.([]interface{})
+		adminMap := admin[0].(map[string]interface{})
+		login = model.FedauthWorkloadIdentity{
+			TenantID:      adminMap["tenant_id"].(string),
+			ClientID:      adminMap["client_id"].(string),
+			TokenFilePath: adminMap["token_file_path"].(string),
+			AuthorityHost: adminMap["authority_host"].(string),
+		}
+	}
+
+	return login
+}
+
 func serverFromId(id string) ([]map[string]interface{}, *url.URL, error) {
+	id, instance := splitInstance(id)
 	u, err := url.Parse(id)
 	if err != nil {
 		return nil, nil, err
@@ -20,8 +340,13 @@ func serverFromId(id string) ([]map[string]interface{}, *url.URL, error) {
 		return nil, nil, errors.New("invalid schema in ID")
 	}
 
+	values := u.Query()
+	if instance == "" {
+		instance = values.Get("instance")
+	}
+
 	host := u.Host
-	port := DefaultPort
+	port := defaultPort("", instance)
 
 	if strings.ContainsRune(host, ':') {
 		var err error
@@ -30,8 +355,6 @@ func serverFromId(id string) ([]map[string]interface{}, *url.URL, error) {
 		}
 	}
 
-	values := u.Query()
-
 	login, loginInValues := getLogin(values)
 	azureLogin, azureInValues := getAzureLogin(values)
 	if login == nil && azureLogin == nil {
@@ -56,11 +379,39 @@ func serverFromId(id string) ([]map[string]interface{}, *url.URL, error) {
 	return []map[string]interface{}{{
 		"host":        host,
 		"port":        port,
+		"instance":    instance,
 		"login":       login,
 		"azure_login": azureLogin,
 	}}, u, nil
 }
 
+// splitInstance removes the instance from IDs written as HOST\INSTANCE, e.g.
+// sqlserver://sql1\SQLEXPRESS/login/l, which are not valid URLs.
+func splitInstance(id string) (string, string) {
+	scheme := strings.Index(id, "://")
+	if scheme < 0 {
+		return id, ""
+	}
+	authorityStart := scheme + len("://")
+	authorityEnd := len(id)
+	if i := strings.IndexAny(id[authorityStart:], "/?"); i >= 0 {
+		authorityEnd = authorityStart + i
+	}
+
+	authority := id[authorityStart:authorityEnd]
+	separator := strings.IndexByte(authority, '\\')
+	if separator < 0 {
+		return id, ""
+	}
+
+	// the port may follow the instance, e.g. HOST\INSTANCE:1433 or HOST\INSTANCE,1433
+	host, instance := authority[:separator], authority[separator+1:]
+	if i := strings.IndexAny(instance, ":,"); i >= 0 {
+		host, instance = host+":"+instance[i+1:], instance[:i]
+	}
+	return id[:authorityStart] + host + id[authorityEnd:], instance
+}
+
 func getLogin(values url.Values) ([]map[string]interface{}, bool) {
 	var inValues bool
 
@@ -112,13 +463,34 @@ func getAzureLogin(values url.Values) ([]map[string]interface{}, bool) {
 		inValues = true
 	}
 
-	if tenantId == "" || clientId == "" || clientSecret == "" {
+	clientCertificatePath := values.Get("client_certificate_path")
+	if clientCertificatePath == "" {
+		clientCertificatePath = os.Getenv("TF_SQLSERVER_CLIENT_CERTIFICATE_PATH")
+	} else {
+		inValues = true
+	}
+
+	clientCertificatePassword := values.Get("client_certificate_password")
+	if clientCertificatePassword == "" {
+		clientCertificatePassword = os.Getenv("TF_SQLSERVER_CLIENT_CERTIFICATE_PASSWORD")
+	} else {
+		inValues = true
+	}
+
+	if tenantId == "" || clientId == "" || (clientSecret == "" && clientCertificatePath == "") {
 		return nil, false
 	}
 
-	return []map[string]interface{}{{
-		"tenant_id":     tenantId,
-		"client_id":     clientId,
-		"client_secret": clientSecret,
-	}}, inValues
+	azureLogin := map[string]interface{}{
+		"tenant_id": tenantId,
+		"client_id": clientId,
+	}
+	if clientCertificatePath != "" {
+		azureLogin["client_certificate_path"] = clientCertificatePath
+		azureLogin["client_certificate_password"] = clientCertificatePassword
+	} else {
+		azureLogin["client_secret"] = clientSecret
+	}
+
+	return []map[string]interface{}{azureLogin}, inValues
 }
@@ -0,0 +1,244 @@
+package sqlserver
+
+import (
+	"testing"
+
+	"terraform-provider-sqlserver/sql"
+	"terraform-provider-sqlserver/sqlserver/model"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestProviderServerOverride(t *testing.T) {
+	provider := sqlserverProvider{
+		host:  "provider-host",
+		port:  DefaultPort,
+		login: model.SqlLogin{Username: "sa", Password: "secret"},
+	}
+
+	tests := []struct {
+		name         string
+		raw          map[string]interface{}
+		wantHost     string
+		wantPort     string
+		wantInstance string
+		wantLogin    interface{}
+		wantID       string
+	}{
+		{
+			name:      "provider defaults",
+			raw:       map[string]interface{}{"sql_login": []interface{}{map[string]interface{}{"login_name": "l", "password": "p"}}},
+			wantHost:  "provider-host",
+			wantPort:  DefaultPort,
+			wantLogin: model.SqlLogin{Username: "sa", Password: "secret"},
+			wantID:    "sqlserver://provider-host:1433/login/l",
+		},
+		{
+			name: "server override keeps provider login",
+			raw: map[string]interface{}{
+				"server":    []interface{}{map[string]interface{}{"host": "other-host", "port": "14330"}},
+				"sql_login": []interface{}{map[string]interface{}{"login_name": "l", "password": "p"}},
+			},
+			wantHost:  "other-host",
+			wantPort:  "14330",
+			wantLogin: model.SqlLogin{Username: "sa", Password: "secret"},
+			wantID:    "sqlserver://other-host:14330/login/l",
+		},
+		{
+			name: "server override with login",
+			raw: map[string]interface{}{
+				"server": []interface{}{map[string]interface{}{
+					"host":  "other-host",
+					"login": []interface{}{map[string]interface{}{"username": "admin", "password": "other"}},
+				}},
+				"sql_login": []interface{}{map[string]interface{}{"login_name": "l", "password": "p"}},
+			},
+			wantHost:  "other-host",
+			wantPort:  DefaultPort,
+			wantLogin: model.SqlLogin{Username: "admin", Password: "other"},
+			wantID:    "sqlserver://other-host:1433/login/l",
+		},
+		{
+			name: "server override with managed identity",
+			raw: map[string]interface{}{
+				"server": []interface{}{map[string]interface{}{
+					"host":                          "other-host",
+					"azuread_managed_identity_auth": []interface{}{map[string]interface{}{"user_id": "id"}},
+				}},
+				"sql_login": []interface{}{map[string]interface{}{"login_name": "l", "password": "p"}},
+			},
+			wantHost:  "other-host",
+			wantPort:  DefaultPort,
+			wantLogin: model.FedauthMSI{UserID: "id"},
+			wantID:    "sqlserver://other-host:1433/login/l",
+		},
+		{
+			name: "server override with named instance",
+			raw: map[string]interface{}{
+				"server":    []interface{}{map[string]interface{}{"host": "other-host", "instance": "SQLEXPRESS"}},
+				"sql_login": []interface{}{map[string]interface{}{"login_name": "l", "password": "p"}},
+			},
+			wantHost:     "other-host",
+			wantInstance: "SQLEXPRESS",
+			wantLogin:    model.SqlLogin{Username: "sa", Password: "secret"},
+			wantID:       "sqlserver://other-host/login/l?instance=SQLEXPRESS",
+		},
+		{
+			name: "server override with named instance and port",
+			raw: map[string]interface{}{
+				"server":    []interface{}{map[string]interface{}{"host": "other-host", "port": "14330", "instance": "SQLEXPRESS"}},
+				"sql_login": []interface{}{map[string]interface{}{"login_name": "l", "password": "p"}},
+			},
+			wantHost:     "other-host",
+			wantPort:     "14330",
+			wantInstance: "SQLEXPRESS",
+			wantLogin:    model.SqlLogin{Username: "sa", Password: "secret"},
+			wantID:       "sqlserver://other-host:14330/login/l?instance=SQLEXPRESS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := schema.TestResourceDataRaw(t, resourceLogin().Schema, tt.raw)
+
+			host, port, instance, login := provider.server(data)
+			if host != tt.wantHost || port != tt.wantPort || instance != tt.wantInstance {
+				t.Fatalf("server() = %s, want %s", sql.ServerName(host, port, instance), sql.ServerName(tt.wantHost, tt.wantPort, tt.wantInstance))
+			}
+			if login != tt.wantLogin {
+				t.Fatalf("server() login = %#v, want %#v", login, tt.wantLogin)
+			}
+			if id := getLoginID(provider, data); id != tt.wantID {
+				t.Fatalf("getLoginID() = %s, want %s", id, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestServerFromIdInstance(t *testing.T) {
+	tests := []struct {
+		name         string
+		id           string
+		wantHost     string
+		wantPort     string
+		wantInstance string
+		wantPath     string
+	}{
+		{
+			name:     "default instance",
+			id:       "sqlserver://host:1433/login/l?username=sa&password=secret",
+			wantHost: "host",
+			wantPort: DefaultPort,
+			wantPath: "/login/l",
+		},
+		{
+			name:     "default port",
+			id:       "sqlserver://host/login/l?username=sa&password=secret",
+			wantHost: "host",
+			wantPort: DefaultPort,
+			wantPath: "/login/l",
+		},
+		{
+			name:         "instance parameter",
+			id:           "sqlserver://host/login/l?instance=SQLEXPRESS&username=sa&password=secret",
+			wantHost:     "host",
+			wantInstance: "SQLEXPRESS",
+			wantPath:     "/login/l",
+		},
+		{
+			name:         "instance parameter with port",
+			id:           "sqlserver://host:14330/app/u?instance=SQLEXPRESS&username=sa&password=secret",
+			wantHost:     "host",
+			wantPort:     "14330",
+			wantInstance: "SQLEXPRESS",
+			wantPath:     "/app/u",
+		},
+		{
+			name:         "instance name",
+			id:           `sqlserver://host\SQLEXPRESS/login/l?username=sa&password=secret`,
+			wantHost:     "host",
+			wantInstance: "SQLEXPRESS",
+			wantPath:     "/login/l",
+		},
+		{
+			name:         "instance name with port",
+			id:           `sqlserver://host\SQLEXPRESS,14330/resource_pool/p?username=sa&password=secret`,
+			wantHost:     "host",
+			wantPort:     "14330",
+			wantInstance: "SQLEXPRESS",
+			wantPath:     "/resource_pool/p",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, u, err := serverFromId(tt.id)
+			if err != nil {
+				t.Fatalf("serverFromId() error = %v", err)
+			}
+			if host := server[0]["host"]; host != tt.wantHost {
+				t.Fatalf("serverFromId() host = %v, want %v", host, tt.wantHost)
+			}
+			if port := server[0]["port"]; port != tt.wantPort {
+				t.Fatalf("serverFromId() port = %v, want %v", port, tt.wantPort)
+			}
+			if instance := server[0]["instance"]; instance != tt.wantInstance {
+				t.Fatalf("serverFromId() instance = %v, want %v", instance, tt.wantInstance)
+			}
+			if u.Path != tt.wantPath {
+				t.Fatalf("serverFromId() path = %v, want %v", u.Path, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestServerSchemaIgnoresEnvironment(t *testing.T) {
+	t.Setenv("TF_SQLSERVER_USERNAME", "env-user")
+	t.Setenv("TF_SQLSERVER_PASSWORD", "env-password")
+	t.Setenv("TF_SQLSERVER_CLIENT_SECRET", "env-secret")
+	t.Setenv("TF_SQLSERVER_ACCESS_TOKEN", "env-token")
+
+	provider := sqlserverProvider{
+		host:  "provider-host",
+		port:  DefaultPort,
+		login: model.SqlLogin{Username: "sa", Password: "secret"},
+	}
+
+	tests := []struct {
+		name      string
+		server    map[string]interface{}
+		wantLogin interface{}
+	}{
+		{
+			name:      "provider login",
+			server:    map[string]interface{}{"host": "other-host"},
+			wantLogin: model.SqlLogin{Username: "sa", Password: "secret"},
+		},
+		{
+			name:      "login without password",
+			server:    map[string]interface{}{"host": "other-host", "login": []interface{}{map[string]interface{}{"username": "admin"}}},
+			wantLogin: model.SqlLogin{Username: "admin"},
+		},
+		{
+			name: "azure login without secret",
+			server: map[string]interface{}{"host": "other-host", "azure_login": []interface{}{map[string]interface{}{
+				"tenant_id": "tenant",
+				"client_id": "client",
+			}}},
+			wantLogin: model.AzureLogin{TenantID: "tenant", ClientID: "client"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := schema.TestResourceDataRaw(t, resourceLogin().Schema, map[string]interface{}{
+				"server":    []interface{}{tt.server},
+				"sql_login": []interface{}{map[string]interface{}{"login_name": "l", "password": "p"}},
+			})
+
+			if _, _, _, login := provider.server(data); login != tt.wantLogin {
+				t.Fatalf("server() login = %#v, want %#v", login, tt.wantLogin)
+			}
+		})
+	}
+}
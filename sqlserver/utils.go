@@ -2,17 +2,12 @@ package sqlserver
 
 import (
 	"fmt"
-	"terraform-provider-sqlserver/sqlserver/model"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"github.com/rs/zerolog"
 )
 
 func getLoginID(meta interface{}, data *schema.ResourceData) string {
-	provider := meta.(sqlserverProvider)
-	host := provider.host
-	port := provider.port
-
 	var loginName string
 	if sqlLoginInterface, ok := data.GetOk("sql_login"); ok {
 		sqlLogin := sqlLoginInterface.([]interface{})
@@ -25,15 +20,10 @@ func getLoginID(meta interface{}, data *schema.ResourceData) string {
 		loginName = login0[loginNameProp].(string)
 	}
 
-	loginID := fmt.Sprintf("sqlserver://%s:%s/login/%s", host, port, loginName)
-
-	return loginID
+	return resourceID(meta, data, "login/"+loginName)
 }
 
 func getUserID(meta interface{}, data *schema.ResourceData) string {
-	provider := meta.(sqlserverProvider)
-	host := provider.host
-	port := provider.port
 	database := data.Get(databaseProp).(string)
 
 	var username string
@@ -51,9 +41,16 @@ func getUserID(meta interface{}, data *schema.ResourceData) string {
 		username = user0[usernameProp].(string)
 	}
 
-	return fmt.Sprintf("sqlserver://%s:%s/%s/%s", host, port, database, username)
+	return resourceID(meta, data, database+"/"+username)
 }
 
-func loggerFromMeta(meta interface{}, resource, function string) zerolog.Logger {
-	return meta.(model.Provider).ResourceLogger(resource, function)
+func validateDuration(i interface{}, k string) ([]string, []error) {
+	v, ok := i.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be string", k)}
+	}
+	if _, err := time.ParseDuration(v); err != nil {
+		return nil, []error{fmt.Errorf("expected %s to be a duration like \"30s\" or \"5m\", got %q", k, v)}
+	}
+	return nil, nil
 }
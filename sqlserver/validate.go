@@ -0,0 +1,141 @@
+package sqlserver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"terraform-provider-sqlserver/sql"
+	"terraform-provider-sqlserver/sqlserver/model"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// defaultValidateTimeout bounds the connect of the validation without a
+// connect_timeout, so that an unreachable server fails the run early.
+const defaultValidateTimeout = 30 * time.Second
+
+type ValidateConnector interface {
+	PingContext(ctx context.Context) error
+	GetServerInfo(ctx context.Context) (*model.ServerInfo, error)
+	GetPrincipal(ctx context.Context, serverPermissions, databasePermissions []string) (*model.Principal, error)
+}
+
+// requiredPermission is a permission the resource types need to manage their
+// objects.
+type requiredPermission struct {
+	permission    string
+	resourceTypes []string
+}
+
+var requiredServerPermissions = []requiredPermission{
+	{permission: "ALTER ANY LOGIN", resourceTypes: []string{"sqlserver_login"}},
+	{permission: "CONTROL SERVER", resourceTypes: []string{"sqlserver_resource_pool", "sqlserver_workload_group", "sqlserver_classifier_function", "sqlserver_resource_governor"}},
+}
+
+var requiredDatabasePermissions = []requiredPermission{
+	{permission: "ALTER ANY USER", resourceTypes: []string{"sqlserver_user"}},
+}
+
+// connectionAttributes are the provider arguments the server is connected with.
+var connectionAttributes = append([]string{"host", "port", "instance", "connection_string"}, LoginMethods...)
+
+// validateProvider validates the server of the provider, unless the arguments
+// it is connected with are not known yet, e.g. as the server is created in the
+// same run.
+func validateProvider(ctx context.Context, data *schema.ResourceData, factory model.ConnectorFactory, host, port, instance string, login interface{}, options model.ConnectionOptions) diag.Diagnostics {
+	server := sql.ServerName(host, port, instance)
+	if unknown := unknownAttributes(data.GetRawConfig(), connectionAttributes); len(unknown) > 0 {
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf("unable to validate %s", server),
+			Detail:   fmt.Sprintf("validate_on_configure is skipped, as the values of %s are not known until apply.", strings.Join(unknown, ", ")),
+		}}
+	}
+
+	if options.ConnectTimeout <= 0 {
+		options.ConnectTimeout = defaultValidateTimeout
+	}
+	connector, err := factory.GetConnector(data, host, port, instance, login, options)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return validateServer(ctx, connector.(ValidateConnector), server)
+}
+
+// unknownAttributes returns the attributes of config whose values are not known.
+func unknownAttributes(config cty.Value, attributes []string) []string {
+	if config.IsNull() || !config.IsKnown() {
+		return nil
+	}
+	var unknown []string
+	for _, name := range attributes {
+		if config.Type().HasAttribute(name) && !config.GetAttr(name).IsWhollyKnown() {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown
+}
+
+// validateServer connects to the server of the provider and returns an error if
+// it cannot, or warnings for the permissions the principal does not hold. The
+// provider does not know which resource types are used, so missing permissions
+// are not errors.
+func validateServer(ctx context.Context, connector ValidateConnector, server string) diag.Diagnostics {
+	if err := connector.PingContext(ctx); err != nil {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("unable to connect to %s", server),
+			Detail:   fmt.Sprintf("%s\n\nThe server is validated as the provider has validate_on_configure set.", err),
+		}}
+	}
+
+	info, err := connector.GetServerInfo(ctx)
+	if err != nil {
+		return diag.Errorf("unable to read the version of %s: %s", server, err)
+	}
+	principal, err := connector.GetPrincipal(ctx, permissionNames(requiredServerPermissions), permissionNames(requiredDatabasePermissions))
+	if err != nil {
+		return diag.Errorf("unable to read the permissions on %s: %s", server, err)
+	}
+	tflog.Info(ctx, fmt.Sprintf("Validated %s", server), map[string]interface{}{
+		"principal": principal.Name,
+		"edition":   info.Edition,
+		"version":   info.ProductVersion,
+	})
+
+	connected := fmt.Sprintf("Connected to %s as %s, running %s %s.", server, principal.Name, info.Edition, info.ProductVersion)
+	var diags diag.Diagnostics
+	for _, required := range requiredServerPermissions {
+		if principal.ServerPermissions[required.permission] {
+			continue
+		}
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf("%s does not hold %s on %s", principal.Name, required.permission, server),
+			Detail:   fmt.Sprintf("%s is needed by %s. %s", required.permission, strings.Join(required.resourceTypes, ", "), connected),
+		})
+	}
+	for _, required := range requiredDatabasePermissions {
+		for _, database := range principal.MissingDatabasePermissions[required.permission] {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  fmt.Sprintf("%s does not hold %s in %s", principal.Name, required.permission, database),
+				Detail:   fmt.Sprintf("%s is needed by %s in the database of the resource. %s", required.permission, strings.Join(required.resourceTypes, ", "), connected),
+			})
+		}
+	}
+	return diags
+}
+
+func permissionNames(permissions []requiredPermission) []string {
+	names := make([]string, 0, len(permissions))
+	for _, required := range permissions {
+		names = append(names, required.permission)
+	}
+	return names
+}
@@ -0,0 +1,180 @@
+package sqlserver
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"terraform-provider-sqlserver/sqlserver/model"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/pkg/errors"
+)
+
+type fakeValidateConnector struct {
+	pingErr   error
+	principal model.Principal
+}
+
+func (c *fakeValidateConnector) PingContext(context.Context) error {
+	return c.pingErr
+}
+
+func (c *fakeValidateConnector) GetServerInfo(context.Context) (*model.ServerInfo, error) {
+	return &model.ServerInfo{EngineEdition: 3, Edition: "Developer Edition (64-bit)", ProductVersion: "16.0.1000.6"}, nil
+}
+
+func (c *fakeValidateConnector) GetPrincipal(_ context.Context, serverPermissions, databasePermissions []string) (*model.Principal, error) {
+	return &c.principal, nil
+}
+
+func TestValidateServer(t *testing.T) {
+	allServerPermissions := map[string]bool{"ALTER ANY LOGIN": true, "CONTROL SERVER": true}
+
+	tests := []struct {
+		name      string
+		connector fakeValidateConnector
+		want      []diag.Diagnostic
+	}{
+		{
+			name:      "all permissions",
+			connector: fakeValidateConnector{principal: model.Principal{Name: "sa", ServerPermissions: allServerPermissions}},
+		},
+		{
+			name:      "unreachable",
+			connector: fakeValidateConnector{pingErr: errors.New("Login failed for user 'terraform'.")},
+			want: []diag.Diagnostic{{
+				Severity: diag.Error,
+				Summary:  "unable to connect to sql1.example.com:1433",
+				Detail:   "Login failed for user 'terraform'.",
+			}},
+		},
+		{
+			name: "no control server",
+			connector: fakeValidateConnector{principal: model.Principal{
+				Name:              "terraform",
+				ServerPermissions: map[string]bool{"ALTER ANY LOGIN": true, "CONTROL SERVER": false},
+			}},
+			want: []diag.Diagnostic{{
+				Severity: diag.Warning,
+				Summary:  "terraform does not hold CONTROL SERVER on sql1.example.com:1433",
+				Detail:   "CONTROL SERVER is needed by sqlserver_resource_pool, sqlserver_workload_group, sqlserver_classifier_function, sqlserver_resource_governor. Connected to sql1.example.com:1433 as terraform, running Developer Edition (64-bit) 16.0.1000.6.",
+			}},
+		},
+		{
+			name: "no alter any user",
+			connector: fakeValidateConnector{principal: model.Principal{
+				Name:                       "terraform",
+				ServerPermissions:          allServerPermissions,
+				MissingDatabasePermissions: map[string][]string{"ALTER ANY USER": {"app", "reporting"}},
+			}},
+			want: []diag.Diagnostic{
+				{
+					Severity: diag.Warning,
+					Summary:  "terraform does not hold ALTER ANY USER in app",
+					Detail:   "ALTER ANY USER is needed by sqlserver_user in the database of the resource.",
+				},
+				{
+					Severity: diag.Warning,
+					Summary:  "terraform does not hold ALTER ANY USER in reporting",
+					Detail:   "ALTER ANY USER is needed by sqlserver_user in the database of the resource.",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := validateServer(context.Background(), &tt.connector, "sql1.example.com:1433")
+			if len(got) != len(tt.want) {
+				t.Fatalf("validateServer() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].Severity != tt.want[i].Severity || got[i].Summary != tt.want[i].Summary || !strings.Contains(got[i].Detail, tt.want[i].Detail) {
+					t.Fatalf("validateServer()[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// validateFactory hands out connector and records the options of the connects.
+type validateFactory struct {
+	model.ConnectorFactory
+	connector *fakeValidateConnector
+	options   []model.ConnectionOptions
+}
+
+func (f *validateFactory) GetConnector(_ *schema.ResourceData, _ string, _ string, _ string, _ interface{}, options model.ConnectionOptions) (interface{}, error) {
+	f.options = append(f.options, options)
+	return f.connector, nil
+}
+
+func TestValidateOnConfigure(t *testing.T) {
+	tests := []struct {
+		name               string
+		config             map[string]cty.Value
+		wantConnects       int
+		wantConnectTimeout time.Duration
+		wantWarning        string
+	}{
+		{
+			name:               "default timeout",
+			config:             map[string]cty.Value{"host": cty.StringVal("sql1.example.com")},
+			wantConnects:       1,
+			wantConnectTimeout: defaultValidateTimeout,
+		},
+		{
+			name:               "connect timeout",
+			config:             map[string]cty.Value{"host": cty.StringVal("sql1.example.com"), "connect_timeout": cty.StringVal("5s")},
+			wantConnects:       1,
+			wantConnectTimeout: 5 * time.Second,
+		},
+		{
+			name:        "unknown host",
+			config:      map[string]cty.Value{"host": cty.UnknownVal(cty.String)},
+			wantWarning: "validate_on_configure is skipped, as the values of host are not known until apply.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factory := &validateFactory{connector: &fakeValidateConnector{principal: model.Principal{
+				Name:              "sa",
+				ServerPermissions: map[string]bool{"ALTER ANY LOGIN": true, "CONTROL SERVER": true},
+			}}}
+			provider := Provider(factory)
+
+			block := schema.InternalMap(provider.Schema).CoreConfigSchema()
+			values := map[string]cty.Value{}
+			for name, attributeType := range block.ImpliedType().AttributeTypes() {
+				values[name] = cty.NullVal(attributeType)
+			}
+			values["validate_on_configure"] = cty.True
+			for name, value := range tt.config {
+				values[name] = value
+			}
+
+			// the raw config is set as by the gRPC server of the SDK
+			config := terraform.NewResourceConfigShimmed(cty.ObjectVal(values), block)
+			config.CtyValue = cty.ObjectVal(values)
+			diags := provider.Configure(context.Background(), config)
+			if diags.HasError() {
+				t.Fatalf("Configure() = %v", diags)
+			}
+			if len(factory.options) != tt.wantConnects {
+				t.Fatalf("Configure() connected %d times, want %d", len(factory.options), tt.wantConnects)
+			}
+			if tt.wantConnects > 0 && factory.options[0].ConnectTimeout != tt.wantConnectTimeout {
+				t.Fatalf("Configure() connect timeout = %s, want %s", factory.options[0].ConnectTimeout, tt.wantConnectTimeout)
+			}
+			if tt.wantWarning != "" && (len(diags) != 1 || diags[0].Detail != tt.wantWarning) {
+				t.Fatalf("Configure() = %v, want warning %q", diags, tt.wantWarning)
+			}
+		})
+	}
+}